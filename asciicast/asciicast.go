@@ -7,14 +7,108 @@ package asciicast
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EventCode identifies the type of an event.
+type EventCode string
+
+const (
+	OutputEvent EventCode = "o"
+	InputEvent  EventCode = "i"
+	MarkerEvent EventCode = "m"
+	ResizeEvent EventCode = "r"
+	ExitEvent   EventCode = "x" // v3 only
 )
 
 type Event struct {
 	Time float64
-	Code string
+	Code EventCode
 	Data string
 }
 
+// WrongCodeError is returned when a payload accessor is used on an event of another type.
+type WrongCodeError struct {
+	Expected EventCode
+	Actual   EventCode
+}
+
+func (w WrongCodeError) Error() string {
+	return fmt.Sprintf("expected %q event, got %q", w.Expected, w.Actual)
+}
+
+// PayloadError is returned when the data of an event can not be parsed.
+type PayloadError struct {
+	Code   EventCode
+	Data   string
+	Reason string
+}
+
+func (p PayloadError) Error() string {
+	return fmt.Sprintf("invalid %q event data %q: %s", p.Code, p.Data, p.Reason)
+}
+
+// NewResizeEvent creates a resize event with the new terminal size.
+func NewResizeEvent(time float64, cols, rows int) Event {
+	return Event{Time: time, Code: ResizeEvent, Data: fmt.Sprintf("%dx%d", cols, rows)}
+}
+
+// NewMarkerEvent creates a marker event with the given label.
+func NewMarkerEvent(time float64, label string) Event {
+	return Event{Time: time, Code: MarkerEvent, Data: label}
+}
+
+// NewExitEvent creates an exit event with the given exit status.
+func NewExitEvent(time float64, status int) Event {
+	return Event{Time: time, Code: ExitEvent, Data: strconv.Itoa(status)}
+}
+
+// Resize returns the terminal size of a resize event.
+func (e Event) Resize() (cols, rows int, err error) {
+	if e.Code != ResizeEvent {
+		return 0, 0, WrongCodeError{Expected: ResizeEvent, Actual: e.Code}
+	}
+
+	colsStr, rowsStr, ok := strings.Cut(e.Data, "x")
+	if !ok {
+		return 0, 0, PayloadError{Code: e.Code, Data: e.Data, Reason: "expected COLSxROWS"}
+	}
+
+	cols, err = strconv.Atoi(colsStr)
+	if err != nil || cols <= 0 {
+		return 0, 0, PayloadError{Code: e.Code, Data: e.Data, Reason: "invalid column count"}
+	}
+
+	rows, err = strconv.Atoi(rowsStr)
+	if err != nil || rows <= 0 {
+		return 0, 0, PayloadError{Code: e.Code, Data: e.Data, Reason: "invalid row count"}
+	}
+
+	return cols, rows, nil
+}
+
+// Marker returns the label of a marker event.
+func (e Event) Marker() (label string, err error) {
+	if e.Code != MarkerEvent {
+		return "", WrongCodeError{Expected: MarkerEvent, Actual: e.Code}
+	}
+	return e.Data, nil
+}
+
+// ExitStatus returns the exit status of an exit event.
+func (e Event) ExitStatus() (status int, err error) {
+	if e.Code != ExitEvent {
+		return 0, WrongCodeError{Expected: ExitEvent, Actual: e.Code}
+	}
+
+	status, err = strconv.Atoi(e.Data)
+	if err != nil {
+		return 0, PayloadError{Code: e.Code, Data: e.Data, Reason: "expected integer exit status"}
+	}
+	return status, nil
+}
+
 var _ json.Marshaler = Event{}
 var _ json.Unmarshaler = &Event{}
 
@@ -41,10 +135,11 @@ func (e *Event) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("wrong type for event time field")
 	}
 
-	e.Code, ok = msg[1].(string)
+	code, ok := msg[1].(string)
 	if !ok {
 		return fmt.Errorf("wrong type for event code field")
 	}
+	e.Code = EventCode(code)
 
 	e.Data, ok = msg[2].(string)
 	if !ok {
@@ -0,0 +1,60 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResize(t *testing.T) {
+	cols, rows, err := NewResizeEvent(1, 100, 50).Resize()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cols != 100 || rows != 50 {
+		t.Errorf("Wrong size (expected 100x50, got %dx%d)", cols, rows)
+	}
+
+	for _, data := range []string{"", "100", "100x", "x50", "ax50", "100x-1", "0x50"} {
+		_, _, err := Event{Code: ResizeEvent, Data: data}.Resize()
+		var payloadErr PayloadError
+		if !errors.As(err, &payloadErr) {
+			t.Errorf("Expected PayloadError for %q, got %v", data, err)
+		}
+	}
+
+	_, _, err = Event{Code: OutputEvent, Data: "100x50"}.Resize()
+	var codeErr WrongCodeError
+	if !errors.As(err, &codeErr) {
+		t.Errorf("Expected WrongCodeError, got %v", err)
+	}
+}
+
+func TestMarker(t *testing.T) {
+	label, err := NewMarkerEvent(1, "chapter 1").Marker()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if label != "chapter 1" {
+		t.Errorf("Wrong label %q", label)
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	status, err := NewExitEvent(1, 130).ExitStatus()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if status != 130 {
+		t.Errorf("Wrong exit status %d", status)
+	}
+
+	_, err = Event{Code: ExitEvent, Data: "done"}.ExitStatus()
+	var payloadErr PayloadError
+	if !errors.As(err, &payloadErr) {
+		t.Errorf("Expected PayloadError, got %v", err)
+	}
+}
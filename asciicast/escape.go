@@ -0,0 +1,55 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Byte escapes
+//
+// Event data is a JSON string, so it can't hold bytes which aren't valid UTF-8;
+// encoding/json replaces them with U+FFFD. EscapeBytes maps each such byte b
+// (always 0x80 or above) to the private use code point U+10FF00+b, in the range
+// U+10FF80 to U+10FFFF. Characters of that range already in the data are escaped
+// the same way, one code point per byte of their UTF-8 encoding, so UnescapeBytes
+// restores any data exactly.
+const (
+	escapeBase  = 0x10FF00
+	escapeFirst = escapeBase + 0x80
+)
+
+// EscapeBytes escapes the bytes of data which aren't valid UTF-8.
+func EscapeBytes(data string) string {
+	var b strings.Builder
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRuneInString(data[i:])
+		if (r == utf8.RuneError && size == 1) || r >= escapeFirst {
+			for _, c := range []byte(data[i : i+size]) {
+				b.WriteRune(escapeBase + rune(c))
+			}
+		} else {
+			b.WriteString(data[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// UnescapeBytes reverses EscapeBytes.
+func UnescapeBytes(data string) string {
+	var b strings.Builder
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRuneInString(data[i:])
+		if r >= escapeFirst && size > 1 {
+			b.WriteByte(byte(r - escapeBase))
+		} else {
+			b.WriteString(data[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
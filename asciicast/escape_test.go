@@ -0,0 +1,36 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEscapeBytes(t *testing.T) {
+	tests := []struct {
+		data    string
+		escaped string
+	}{
+		{"hello", "hello"},
+		{"caf\xe9", "caf\U0010FFE9"},
+		{"日本\xff", "日本\U0010FFFF"},
+		{"\U0010FF80", "\U0010FFF4\U0010FF8F\U0010FFBE\U0010FF80"},
+		{"\U0010FF7F", "\U0010FF7F"},
+	}
+
+	for _, test := range tests {
+		escaped := EscapeBytes(test.data)
+		if escaped != test.escaped {
+			t.Errorf("EscapeBytes(%q) = %q, expected %q", test.data, escaped, test.escaped)
+		}
+		if !utf8.ValidString(escaped) {
+			t.Errorf("EscapeBytes(%q) is not valid UTF-8", test.data)
+		}
+		if unescaped := UnescapeBytes(escaped); unescaped != test.data {
+			t.Errorf("UnescapeBytes(%q) = %q, expected %q", escaped, unescaped, test.data)
+		}
+	}
+}
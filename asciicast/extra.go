@@ -0,0 +1,150 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+)
+
+// Unknown header fields are kept in an Extra map so that they survive a
+// decode/encode round trip. Known fields always take precedence over extras.
+
+var _ json.Marshaler = HeaderV1{}
+var _ json.Unmarshaler = &HeaderV1{}
+var _ json.Marshaler = HeaderV2{}
+var _ json.Unmarshaler = &HeaderV2{}
+var _ json.Marshaler = HeaderV3{}
+var _ json.Unmarshaler = &HeaderV3{}
+var _ json.Marshaler = TermInfo{}
+var _ json.Unmarshaler = &TermInfo{}
+
+func (h HeaderV1) MarshalJSON() ([]byte, error) {
+	type plain HeaderV1
+	return marshalWithExtra(plain(h), h.Extra)
+}
+
+func (h *HeaderV1) UnmarshalJSON(data []byte) error {
+	type plain HeaderV1
+	extra, err := unmarshalWithExtra(data, (*plain)(h))
+	h.Extra = extra
+	delete(h.Extra, "stdout") // events are read separately
+	return err
+}
+
+func (h HeaderV2) MarshalJSON() ([]byte, error) {
+	type plain HeaderV2
+	return marshalWithExtra(plain(h), h.Extra)
+}
+
+func (h *HeaderV2) UnmarshalJSON(data []byte) error {
+	type plain HeaderV2
+	extra, err := unmarshalWithExtra(data, (*plain)(h))
+	h.Extra = extra
+	return err
+}
+
+func (h HeaderV3) MarshalJSON() ([]byte, error) {
+	type plain HeaderV3
+	return marshalWithExtra(plain(h), h.Extra)
+}
+
+func (h *HeaderV3) UnmarshalJSON(data []byte) error {
+	type plain HeaderV3
+	extra, err := unmarshalWithExtra(data, (*plain)(h))
+	h.Extra = extra
+	return err
+}
+
+func (t TermInfo) MarshalJSON() ([]byte, error) {
+	type plain TermInfo
+	return marshalWithExtra(plain(t), t.Extra)
+}
+
+func (t *TermInfo) UnmarshalJSON(data []byte) error {
+	type plain TermInfo
+	extra, err := unmarshalWithExtra(data, (*plain)(t))
+	t.Extra = extra
+	return err
+}
+
+// marshalWithExtra encodes v, then appends the fields of extra that v does not already have.
+func marshalWithExtra(v any, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	var known map[string]json.RawMessage
+	if err := json.Unmarshal(data, &known); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		if _, ok := known[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var buf bytes.Buffer
+	buf.Write(bytes.TrimSuffix(data, []byte("}")))
+	empty := len(known) == 0
+	for _, key := range keys {
+		if !empty {
+			buf.WriteByte(',')
+		}
+		empty = false
+
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+
+		var value bytes.Buffer
+		if err := json.Compact(&value, extra[key]); err != nil {
+			return nil, err
+		}
+		buf.Write(value.Bytes())
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// unmarshalWithExtra decodes data into v and returns the fields that v does not model.
+func unmarshalWithExtra(data []byte, v any) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	// Fields that v encodes are known
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var known map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &known); err != nil {
+		return nil, err
+	}
+
+	for key := range known {
+		delete(fields, key)
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
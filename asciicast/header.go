@@ -29,7 +29,8 @@ type Header interface {
 	IdleTimeLimit() (idleTimeLimit int, ok bool)
 	Env() map[string]string
 	Theme() map[string]string
-	RelativeTime() bool // interpret time as relative
+	RelativeTime() bool                // interpret time as relative
+	Extra() map[string]json.RawMessage // header fields not modeled above
 }
 
 func DecodeHeader(rawHeader []byte) (Header, error) {
@@ -42,6 +43,10 @@ func DecodeHeader(rawHeader []byte) (Header, error) {
 	}
 
 	switch versionExtractor.Version {
+	case 1:
+		var h HeaderV1
+		err := json.Unmarshal(rawHeader, &h)
+		return HeaderV1Iface{Header: h}, err
 	case 2:
 		var h HeaderV2
 		err := json.Unmarshal(rawHeader, &h)
@@ -55,6 +60,90 @@ func DecodeHeader(rawHeader []byte) (Header, error) {
 	}
 }
 
+// HeaderV1 is the metadata of a v1 asciicast.
+// Unlike later versions, a v1 asciicast is a single JSON document
+// with the events stored in its "stdout" field.
+type HeaderV1 struct {
+	Version  int               `json:"version"`
+	Width    int               `json:"width"`
+	Height   int               `json:"height"`
+	Duration *float64          `json:"duration"`
+	Command  *string           `json:"command"`
+	Title    *string           `json:"title"`
+	Env      map[string]string `json:"env"`
+
+	Extra map[string]json.RawMessage `json:"-"` // unknown fields
+}
+
+// Wrapper to avoid field/method collisions
+type HeaderV1Iface struct {
+	Header HeaderV1
+}
+
+var _ Header = HeaderV1Iface{}
+
+func (h HeaderV1Iface) Version() int {
+	return h.Header.Version
+}
+
+func (h HeaderV1Iface) Width() int {
+	return h.Header.Width
+}
+
+func (h HeaderV1Iface) Height() int {
+	return h.Header.Height
+}
+
+func (h HeaderV1Iface) Term() (term string, ok bool) {
+	term, ok = h.Header.Env["TERM"]
+	return
+}
+
+func (h HeaderV1Iface) Timestamp() (timestamp int64, ok bool) {
+	return 0, false
+}
+
+func (h HeaderV1Iface) Duration() (duration float64, ok bool) {
+	if h.Header.Duration == nil {
+		return 0, false
+	}
+	return *h.Header.Duration, true
+}
+
+func (h HeaderV1Iface) Command() (command string, ok bool) {
+	if h.Header.Command == nil {
+		return "", false
+	}
+	return *h.Header.Command, true
+}
+
+func (h HeaderV1Iface) Title() (title string, ok bool) {
+	if h.Header.Title == nil {
+		return "", false
+	}
+	return *h.Header.Title, true
+}
+
+func (h HeaderV1Iface) IdleTimeLimit() (idleTimeLimit int, ok bool) {
+	return 0, false
+}
+
+func (h HeaderV1Iface) Env() map[string]string {
+	return h.Header.Env
+}
+
+func (h HeaderV1Iface) Theme() map[string]string {
+	return nil
+}
+
+func (h HeaderV1Iface) Extra() map[string]json.RawMessage {
+	return h.Header.Extra
+}
+
+func (h HeaderV1Iface) RelativeTime() bool {
+	return true
+}
+
 type HeaderV2 struct {
 	Version       int               `json:"version"`
 	Width         int               `json:"width"`
@@ -66,6 +155,8 @@ type HeaderV2 struct {
 	IdleTimeLimit *int              `json:"idle_time_limit"`
 	Env           map[string]string `json:"env"`
 	Theme         map[string]string `json:"theme"`
+
+	Extra map[string]json.RawMessage `json:"-"` // unknown fields
 }
 
 // Wrapper to avoid field/method collisions
@@ -135,6 +226,10 @@ func (h HeaderV2Iface) Theme() map[string]string {
 	return h.Header.Theme
 }
 
+func (h HeaderV2Iface) Extra() map[string]json.RawMessage {
+	return h.Header.Extra
+}
+
 func (h HeaderV2Iface) RelativeTime() bool {
 	return false
 }
@@ -145,6 +240,8 @@ type TermInfo struct {
 	Type    *string           `json:"type"`
 	Version *string           `json:"version"`
 	Theme   map[string]string `json:"theme"`
+
+	Extra map[string]json.RawMessage `json:"-"` // unknown fields
 }
 
 type HeaderV3 struct {
@@ -156,6 +253,8 @@ type HeaderV3 struct {
 	Title         *string           `json:"title"`
 	IdleTimeLimit *int              `json:"idle_time_limit"`
 	Env           map[string]string `json:"env"`
+
+	Extra map[string]json.RawMessage `json:"-"` // unknown fields
 }
 
 // Wrapper to avoid field/method collisions
@@ -227,6 +326,10 @@ func (h HeaderV3Iface) Theme() map[string]string {
 	return h.Header.Term.Theme
 }
 
+func (h HeaderV3Iface) Extra() map[string]json.RawMessage {
+	return h.Header.Extra
+}
+
 func (h HeaderV3Iface) RelativeTime() bool {
 	return true
 }
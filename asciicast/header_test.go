@@ -0,0 +1,104 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestExtraRoundtrip(t *testing.T) {
+	raw := `{"version": 2, "width": 80, "height": 24, "tags": ["demo", "ci"], "x-vendor": {"id": 7}}`
+	expected := map[string]json.RawMessage{
+		"tags":     json.RawMessage(`["demo","ci"]`),
+		"x-vendor": json.RawMessage(`{"id":7}`),
+	}
+
+	header, err := DecodeHeader([]byte(raw))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, version := range []int{2, 3} {
+		var buf bytes.Buffer
+		if _, err := NewWriter(&buf, version, header); err != nil {
+			t.Fatalf("v%d: Error writing header: %v", version, err)
+		}
+
+		decoded, err := DecodeHeader(buf.Bytes())
+		if err != nil {
+			t.Fatalf("v%d: Error decoding written header: %v", version, err)
+		}
+
+		extra := map[string]json.RawMessage{}
+		for key, value := range decoded.Extra() {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, value); err != nil {
+				t.Fatalf("v%d: Invalid extra field %q: %v", version, key, err)
+			}
+			extra[key] = compact.Bytes()
+		}
+
+		if !reflect.DeepEqual(extra, expected) {
+			t.Errorf("v%d: Extra fields not preserved:\nExpected: %s\nActual:   %s", version, expected, extra)
+		}
+	}
+}
+
+func TestExtraDoesNotOverrideKnownFields(t *testing.T) {
+	header := HeaderV2{
+		Version: 2,
+		Width:   80,
+		Extra:   map[string]json.RawMessage{"width": json.RawMessage(`1`)},
+	}
+
+	data, err := json.Marshal(header)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var decoded HeaderV2
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v (%s)", err, data)
+	}
+
+	if decoded.Width != 80 {
+		t.Errorf("Known field overridden by extra: %s", data)
+	}
+}
+
+func TestTermExtraRoundtrip(t *testing.T) {
+	raw := `{"version": 3, "term": {"cols": 80, "rows": 24, "x_vendor": 1}, "x_top": true}`
+
+	header, err := DecodeHeader([]byte(raw))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := NewWriter(&buf, 3, header); err != nil {
+		t.Fatalf("Error writing header: %v", err)
+	}
+
+	var decoded HeaderV3
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Error decoding written header: %v", err)
+	}
+
+	if decoded.Term.Cols != 80 || decoded.Term.Rows != 24 {
+		t.Errorf("Wrong size %dx%d", decoded.Term.Cols, decoded.Term.Rows)
+	}
+	if expected := map[string]json.RawMessage{"x_vendor": json.RawMessage(`1`)}; !reflect.DeepEqual(decoded.Term.Extra, expected) {
+		t.Errorf("Term extra fields not preserved:\nExpected: %s\nActual:   %s", expected, decoded.Term.Extra)
+	}
+	if _, ok := decoded.Extra["term"]; ok {
+		t.Errorf("Term field kept as an extra: %s", buf.String())
+	}
+	if string(decoded.Extra["x_top"]) != "true" {
+		t.Errorf("Header extra fields not preserved: %s", decoded.Extra)
+	}
+}
@@ -0,0 +1,143 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/wk-y/asciicast2script/internal/timing"
+)
+
+// Reader decodes an asciicast stream event by event.
+//
+// Event times returned by Next are always absolute (seconds since the start
+// of the recording), regardless of whether the underlying file uses absolute
+// (v2) or relative (v1, v3) timing.
+type Reader struct {
+	r      *bufio.Reader
+	header Header
+	frames []frameV1 // remaining events of a v1 asciicast
+	clock  timing.Clock
+}
+
+// NewReader reads and decodes the header of the asciicast in r.
+//
+// A v1 asciicast is a single JSON document, so it is read completely.
+func NewReader(r io.Reader) (*Reader, error) {
+	// Decoding the first JSON value handles both the header line of
+	// v2 and v3 asciicasts and (possibly multi-line) v1 documents.
+	decoder := json.NewDecoder(r)
+	var rawHeader json.RawMessage
+	if err := decoder.Decode(&rawHeader); err != nil {
+		return nil, err
+	}
+
+	header, err := DecodeHeader(rawHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &Reader{
+		r:      bufio.NewReader(io.MultiReader(decoder.Buffered(), r)),
+		header: header,
+	}
+
+	if header.Version() == 1 {
+		var document struct {
+			Stdout []frameV1 `json:"stdout"`
+		}
+		if err := json.Unmarshal(rawHeader, &document); err != nil {
+			return nil, err
+		}
+		reader.frames = document.Stdout
+	}
+
+	return reader, nil
+}
+
+// Header returns the header of the asciicast.
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the next event in the asciicast.
+// It returns io.EOF when there are no more events.
+func (r *Reader) Next() (Event, error) {
+	event, err := r.next()
+	if err != nil {
+		return event, err
+	}
+
+	if r.header.RelativeTime() {
+		event.Time = r.clock.Advance(event.Time)
+	}
+
+	return event, nil
+}
+
+func (r *Reader) next() (Event, error) {
+	if r.header.Version() == 1 {
+		if len(r.frames) == 0 {
+			return Event{}, io.EOF
+		}
+
+		frame := r.frames[0]
+		r.frames = r.frames[1:]
+		return Event{Time: frame.Delay, Code: "o", Data: frame.Data}, nil
+	}
+
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return Event{}, err
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' { // blank or comment line
+			// todo: error if comment encountered in v2 file?
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return Event{}, err
+		}
+
+		return event, nil
+	}
+}
+
+// An entry of a v1 asciicast's "stdout" array
+type frameV1 struct {
+	Delay float64
+	Data  string
+}
+
+var _ json.Unmarshaler = &frameV1{}
+
+func (f *frameV1) UnmarshalJSON(data []byte) error {
+	var msg []json.RawMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+
+	if len(msg) != 2 {
+		return fmt.Errorf("expected 2 fields in v1 frame, got %d", len(msg))
+	}
+
+	if err := json.Unmarshal(msg[0], &f.Delay); err != nil {
+		return fmt.Errorf("wrong type for frame delay field")
+	}
+
+	if err := json.Unmarshal(msg[1], &f.Data); err != nil {
+		return fmt.Errorf("wrong type for frame data field")
+	}
+
+	return nil
+}
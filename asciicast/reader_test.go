@@ -0,0 +1,82 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	testCases := []struct {
+		cast     string
+		version  int
+		expected []Event
+	}{
+		{
+			cast: `{"version": 2, "width": 80, "height": 24}` + "\n" +
+				`[0.5, "o", "hello"]` + "\n" +
+				"\n" +
+				`[1.25, "i", "x"]`, // no trailing newline
+			version: 2,
+			expected: []Event{
+				{Time: 0.5, Code: "o", Data: "hello"},
+				{Time: 1.25, Code: "i", Data: "x"},
+			},
+		},
+		{
+			cast: `{"version": 3, "term": {"cols": 80, "rows": 24}}` + "\n" +
+				"# a comment\n" +
+				`[0.5, "o", "hello"]` + "\n" +
+				`[0.75, "o", "world"]` + "\n",
+			version: 3,
+			expected: []Event{
+				{Time: 0.5, Code: "o", Data: "hello"},
+				{Time: 1.25, Code: "o", Data: "world"},
+			},
+		},
+		{
+			cast: "{\n" +
+				`  "version": 1, "width": 80, "height": 24,` + "\n" +
+				`  "env": {"TERM": "xterm"},` + "\n" +
+				`  "stdout": [[0.5, "hello"], [0.75, "world"]]` + "\n" +
+				"}\n",
+			version: 1,
+			expected: []Event{
+				{Time: 0.5, Code: "o", Data: "hello"},
+				{Time: 1.25, Code: "o", Data: "world"},
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		reader, err := NewReader(strings.NewReader(testCase.cast))
+		if err != nil {
+			t.Fatalf("Test %d: Unexpected error: %v", i, err)
+		}
+
+		if v := reader.Header().Version(); v != testCase.version {
+			t.Fatalf("Test %d: Wrong version (expected %d, got %d)", i, testCase.version, v)
+		}
+
+		var events []Event
+		for {
+			event, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Test %d: Unexpected error: %v", i, err)
+			}
+			events = append(events, event)
+		}
+
+		if !reflect.DeepEqual(events, testCase.expected) {
+			t.Fatalf("Test %d:\nExpected: %#v\nActual:   %#v", i, testCase.expected, events)
+		}
+	}
+}
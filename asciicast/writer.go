@@ -0,0 +1,145 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+
+	"github.com/wk-y/asciicast2script/internal/timing"
+)
+
+// Writer encodes an asciicast stream of a given version.
+//
+// Events passed to Write must use absolute time (seconds since the start of
+// the recording). The Writer converts them to the time base of the target version.
+type Writer struct {
+	encoder *json.Encoder
+	header  Header
+	delta   timing.Delta
+}
+
+// NewWriter writes header to w in the format of the given asciicast version.
+func NewWriter(w io.Writer, version int, header Header) (*Writer, error) {
+	var encoded any
+	var converted Header
+	switch version {
+	case 2:
+		h := ToHeaderV2(header)
+		encoded, converted = h, HeaderV2Iface{Header: h}
+	case 3:
+		h := ToHeaderV3(header)
+		encoded, converted = h, HeaderV3Iface{Header: h}
+	default:
+		return nil, UnsupportedVersionError{Version: version}
+	}
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(encoded); err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		encoder: encoder,
+		header:  converted,
+	}, nil
+}
+
+// Header returns the header as it was written.
+func (w *Writer) Header() Header {
+	return w.header
+}
+
+// Write writes an event with an absolute timestamp.
+// Relative times are rounded to microseconds, and are zero for events
+// earlier than the previous event.
+func (w *Writer) Write(event Event) error {
+	if w.header.RelativeTime() {
+		event.Time = w.delta.Elapsed(event.Time)
+	}
+
+	return w.encoder.Encode(event)
+}
+
+// ToHeaderV2 converts any header to a v2 header.
+func ToHeaderV2(header Header) HeaderV2 {
+	if h, ok := header.(HeaderV2Iface); ok {
+		return h.Header
+	}
+
+	result := HeaderV2{
+		Version: 2,
+		Width:   header.Width(),
+		Height:  header.Height(),
+		Env:     maps.Clone(header.Env()),
+		Theme:   maps.Clone(header.Theme()),
+		Extra:   maps.Clone(header.Extra()),
+	}
+
+	if term, ok := header.Term(); ok {
+		if result.Env == nil {
+			result.Env = map[string]string{}
+		}
+		result.Env["TERM"] = term
+	}
+
+	if timestamp, ok := header.Timestamp(); ok {
+		result.Timestamp = &timestamp
+	}
+	if duration, ok := header.Duration(); ok {
+		result.Duration = &duration
+	}
+	if command, ok := header.Command(); ok {
+		result.Command = &command
+	}
+	if title, ok := header.Title(); ok {
+		result.Title = &title
+	}
+	if idleTimeLimit, ok := header.IdleTimeLimit(); ok {
+		result.IdleTimeLimit = &idleTimeLimit
+	}
+
+	return result
+}
+
+// ToHeaderV3 converts any header to a v3 header.
+func ToHeaderV3(header Header) HeaderV3 {
+	if h, ok := header.(HeaderV3Iface); ok {
+		return h.Header
+	}
+
+	result := HeaderV3{
+		Version: 3,
+		Term: TermInfo{
+			Cols:  header.Width(),
+			Rows:  header.Height(),
+			Theme: maps.Clone(header.Theme()),
+		},
+		Env:   maps.Clone(header.Env()),
+		Extra: maps.Clone(header.Extra()),
+	}
+
+	if term, ok := header.Term(); ok {
+		result.Term.Type = &term
+	}
+	if timestamp, ok := header.Timestamp(); ok {
+		result.Timestamp = &timestamp
+	}
+	if duration, ok := header.Duration(); ok {
+		result.Duration = &duration
+	}
+	if command, ok := header.Command(); ok {
+		result.Command = &command
+	}
+	if title, ok := header.Title(); ok {
+		result.Title = &title
+	}
+	if idleTimeLimit, ok := header.IdleTimeLimit(); ok {
+		result.IdleTimeLimit = &idleTimeLimit
+	}
+
+	return result
+}
@@ -0,0 +1,69 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package asciicast
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestWriterRoundtrip(t *testing.T) {
+	term := "xterm-256color"
+	header := HeaderV3Iface{Header: HeaderV3{
+		Version: 3,
+		Term:    TermInfo{Cols: 80, Rows: 24, Type: &term},
+	}}
+
+	events := []Event{
+		{Time: 0.5, Code: "o", Data: "hello"},
+		{Time: 1.25, Code: "i", Data: "x"},
+		{Time: 3, Code: "o", Data: "world"},
+	}
+
+	for _, version := range []int{2, 3} {
+		var buf bytes.Buffer
+		writer, err := NewWriter(&buf, version, header)
+		if err != nil {
+			t.Fatalf("v%d: Error creating writer: %v", version, err)
+		}
+
+		for i, event := range events {
+			if err := writer.Write(event); err != nil {
+				t.Fatalf("v%d: Error writing event %d: %v", version, i, err)
+			}
+		}
+
+		reader, err := NewReader(&buf)
+		if err != nil {
+			t.Fatalf("v%d: Error reading header: %v", version, err)
+		}
+
+		h := reader.Header()
+		if h.Version() != version {
+			t.Fatalf("v%d: Wrong version %d", version, h.Version())
+		}
+		if got, ok := h.Term(); !ok || got != term {
+			t.Fatalf("v%d: Wrong term %q", version, got)
+		}
+
+		var eventsOut []Event
+		for {
+			event, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("v%d: Error reading event: %v", version, err)
+			}
+			eventsOut = append(eventsOut, event)
+		}
+
+		if !reflect.DeepEqual(events, eventsOut) {
+			t.Errorf("v%d: Decoded events not the same as original:\nExpected: %#v\nActual:   %#v", version, events, eventsOut)
+		}
+	}
+}
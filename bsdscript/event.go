@@ -0,0 +1,112 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package bsdscript reads and writes the binary recordings of
+// FreeBSD and macOS "script -r".
+//
+// A recording is a sequence of records, each made of a header
+//
+//	uint64 length of data
+//	uint64 seconds since the unix epoch
+//	uint32 microseconds
+//	uint32 direction ('s', 'i', 'o' or 'e')
+//
+// followed by the data. The integers use the byte order of the recording
+// machine, which is detected from the direction field.
+package bsdscript
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Record directions
+const (
+	StartDirection  = 's'
+	InputDirection  = 'i'
+	OutputDirection = 'o'
+	EndDirection    = 'e'
+)
+
+// Size of a record header
+const headerSize = 24
+
+// Longest record data read. The length field is 64 bits wide, so a corrupt
+// header could otherwise ask for more memory than the machine has.
+const maxLength = 1 << 30
+
+type Event struct {
+	Data      string
+	Time      time.Time
+	Direction rune
+}
+
+// Take reads the next record of a recording.
+// It returns io.EOF at the end of the recording, and an error wrapping
+// io.ErrUnexpectedEOF if the recording ends within a record, as it does
+// when script is killed while writing one.
+func (e *Event) Take(recording io.Reader) error {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(recording, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("truncated record header: %w", err)
+		}
+		return err
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if order.Uint32(header[20:]) > 0xff {
+		order = binary.BigEndian
+	}
+
+	length := order.Uint64(header[0:])
+	sec := order.Uint64(header[8:])
+	usec := order.Uint32(header[16:])
+	direction := order.Uint32(header[20:])
+
+	if direction > 0xff {
+		return fmt.Errorf("invalid record direction %#x", direction)
+	}
+
+	if length > maxLength {
+		return fmt.Errorf("record length %d too large", length)
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(recording, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("truncated record data: %w", io.ErrUnexpectedEOF)
+		}
+		return err
+	}
+
+	e.Data = string(buf)
+	e.Time = time.Unix(int64(sec), int64(usec)*int64(time.Microsecond))
+	e.Direction = rune(direction)
+
+	return nil
+}
+
+// Write writes the event as a record with the given byte order.
+// Recordings made by script use the byte order of the recording machine,
+// which is binary.LittleEndian on most systems.
+func (e *Event) Write(recording io.Writer, order binary.ByteOrder) error {
+	var header [headerSize]byte
+	order.PutUint64(header[0:], uint64(len(e.Data)))
+	order.PutUint64(header[8:], uint64(e.Time.Unix()))
+	order.PutUint32(header[16:], uint32(e.Time.Nanosecond()/int(time.Microsecond)))
+	order.PutUint32(header[20:], uint32(e.Direction))
+
+	if _, err := recording.Write(header[:]); err != nil {
+		return err
+	}
+
+	if _, err := recording.Write([]byte(e.Data)); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package bsdscript
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRoundtrip(t *testing.T) {
+	start := time.Unix(1743536096, 250000*int64(time.Microsecond))
+	events := []Event{
+		{Time: start, Direction: StartDirection},
+		{Data: "hello", Time: start.Add(500 * time.Millisecond), Direction: OutputDirection},
+		{Data: "ls\r", Time: start.Add(time.Second), Direction: InputDirection},
+		{Time: start.Add(2 * time.Second), Direction: EndDirection},
+	}
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		var recording bytes.Buffer
+		for i, event := range events {
+			if err := event.Write(&recording, order); err != nil {
+				t.Fatalf("%v: Error writing event %d: %v", order, i, err)
+			}
+		}
+
+		eventsOut := make([]Event, len(events))
+		for i := range eventsOut {
+			if err := eventsOut[i].Take(&recording); err != nil {
+				t.Fatalf("%v: Error reading event %d: %v", order, i, err)
+			}
+		}
+
+		for i := range events {
+			if !events[i].Time.Equal(eventsOut[i].Time) {
+				t.Errorf("%v: Wrong time for event %d: %v", order, i, eventsOut[i].Time)
+			}
+			eventsOut[i].Time = events[i].Time
+		}
+
+		if !reflect.DeepEqual(events, eventsOut) {
+			t.Errorf("%v: Decoded events not the same as original:\nExpected: %#v\nActual:   %#v", order, events, eventsOut)
+		}
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	// A big-endian output record of "hello"
+	record := []byte{
+		0, 0, 0, 0, 0, 0, 0, 5, // length
+		0, 0, 0, 0, 0x67, 0xec, 0x3f, 0xe0, // seconds
+		0, 0, 0, 0, // microseconds
+		0, 0, 0, 'o', // direction
+		'h', 'e', 'l', 'l', 'o',
+	}
+
+	// Cut off in the direction field, before the data, and in the data
+	for _, length := range []int{22, headerSize, headerSize + 2} {
+		var event Event
+		err := event.Take(bytes.NewReader(record[:length]))
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%d bytes: Expected io.ErrUnexpectedEOF, got %v", length, err)
+		}
+	}
+
+	var event Event
+	if err := event.Take(bytes.NewReader(record)); err != nil || event.Data != "hello" {
+		t.Errorf("Whole record: got %#v, %v", event, err)
+	}
+}
@@ -5,27 +5,32 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
-	"strings"
-	"time"
 
-	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/convert"
 	"github.com/wk-y/asciicast2script/script"
 )
 
 var typescriptPath string
+var inputPath string
 var timingfilePath string
 var overwrite bool
+var skipInput bool
+var timingFormat string
+var bsd bool
 
 func init() {
 	flag.StringVar(&typescriptPath, "typescript", "typescript", "output typescript file")
+	flag.StringVar(&inputPath, "log-in", "", "write input to a separate file, like script --log-in")
 	flag.StringVar(&timingfilePath, "timingfile", "timingfile", "output timing file")
 	flag.BoolVar(&overwrite, "overwrite", false, "overwrite existing files")
+	flag.BoolVar(&skipInput, "skip-input", false, "drop input events")
+	flag.BoolVar(&bsd, "bsd", false, "write -typescript as a BSD/macOS script -r recording (no timing file)")
+	flag.StringVar(&timingFormat, "format", "advanced", "timing file format: advanced or classic (output only, requires -skip-input for casts with input)")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... ASCIICAST\n\n", os.Args[0])
 		flag.PrintDefaults()
@@ -43,6 +48,25 @@ func main() {
 
 	castFile := argv[0]
 
+	var classic bool
+	switch timingFormat {
+	case "advanced":
+	case "classic":
+		classic = true
+		if inputPath != "" {
+			fmt.Fprintln(os.Stderr, "-log-in can not be used with the classic timing format")
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown timing format %q\n", timingFormat)
+		os.Exit(1)
+	}
+
+	if bsd && (inputPath != "" || classic) {
+		fmt.Fprintln(os.Stderr, "-bsd can not be used with -log-in or the classic timing format")
+		os.Exit(1)
+	}
+
 	cast := os.Stdin
 	if castFile != "-" {
 		var err error
@@ -60,11 +84,20 @@ func main() {
 		outFlags |= os.O_TRUNC
 	}
 
-	script, err := os.OpenFile(typescriptPath, outFlags, 0644)
+	typescript, err := os.OpenFile(typescriptPath, outFlags, 0644)
 	if err != nil {
 		panic(err)
 	}
-	defer script.Close()
+	defer typescript.Close()
+
+	if bsd {
+		err := convert.AsciicastToBSD(cast, typescript, convert.ScriptOptions{SkipInput: skipInput})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	timing, err := os.OpenFile(timingfilePath, outFlags, 0644)
 	if err != nil {
@@ -72,96 +105,29 @@ func main() {
 	}
 	defer timing.Close()
 
-	err = asciicastToScript(cast, script, timing)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
-}
-
-func asciicastToScript(cast io.Reader, typescript, timingfile io.Writer) error {
-	buffered := bufio.NewReader(cast)
-
-	// Convert the header line
-	headerBytes, err := buffered.ReadBytes('\n')
-	if err != nil {
-		return err
-	}
-
-	header, err := asciicast.DecodeHeader(headerBytes)
-	if err != nil {
-		return err
-	}
-
-	fmt.Fprintln(typescript, asciicastHeaderToScript(header))
-
-	// Convert events
-	var previousEventTime float64
-	for {
-		line, err := buffered.ReadBytes('\n')
+	var input io.Writer
+	if inputPath != "" {
+		file, err := os.OpenFile(inputPath, outFlags, 0644)
 		if err != nil {
-			if err == io.EOF {
-				return nil
-			}
-			return err
-		}
-
-		if strings.HasPrefix(string(line), "#") { // comment line
-			// todo: error if comment encountered in v2 file?
-			continue
+			panic(err)
 		}
+		defer file.Close()
+		input = file
+	}
 
-		var acEvent asciicast.Event
-		if err := json.Unmarshal(line, &acEvent); err != nil {
-			if err == io.EOF {
-				return nil
+	err = convert.AsciicastToScriptStreams(cast, typescript, input, timing, convert.ScriptOptions{
+		SkipInput: skipInput,
+		Classic:   classic,
+	})
+	if err != nil {
+		if errors.Is(err, script.ErrClassicInput) {
+			// Don't leave typescripts cut off at the first input event
+			for _, path := range []string{typescriptPath, timingfilePath} {
+				os.Remove(path)
 			}
-			return err
+			err = fmt.Errorf("%w; use -skip-input to drop input events", err)
 		}
-
-		sEvent := script.Event{
-			Data: acEvent.Data,
-		}
-
-		if header.RelativeTime() {
-			sEvent.ElapsedSeconds = acEvent.Time
-		} else {
-			sEvent.ElapsedSeconds = acEvent.Time - previousEventTime
-		}
-
-		var ignore bool
-		switch acEvent.Code {
-		case "i":
-			sEvent.Code = 'I'
-		case "o":
-			sEvent.Code = 'O'
-		default:
-			ignore = true
-		}
-
-		if ignore {
-			continue
-		}
-
-		if err := sEvent.WriteAdvanced(typescript, timingfile); err != nil {
-			return err
-		}
-
-		previousEventTime = acEvent.Time
-	}
-}
-
-func asciicastHeaderToScript(header asciicast.Header) script.Header {
-	var result script.Header
-	if timestamp, ok := header.Timestamp(); ok {
-		result.Start = time.Unix(timestamp, 0)
-	}
-
-	if term, ok := header.Term(); ok {
-		result.Term = term
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-
-	result.Columns = header.Width()
-	result.Lines = header.Height()
-	return result
 }
@@ -0,0 +1,65 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/wk-y/asciicast2script/convert"
+)
+
+var iologPath string
+var overwrite bool
+var skipInput bool
+
+func init() {
+	flag.StringVar(&iologPath, "iolog", "iolog", "output sudo I/O log directory")
+	flag.BoolVar(&overwrite, "overwrite", false, "overwrite an existing I/O log")
+	flag.BoolVar(&skipInput, "skip-input", false, "drop input events")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... ASCIICAST\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	argv := flag.Args()
+	if len(argv) != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	castFile := argv[0]
+
+	cast := os.Stdin
+	if castFile != "-" {
+		var err error
+		cast, err = os.Open(castFile)
+		if err != nil {
+			panic(err)
+		}
+		defer cast.Close()
+	}
+
+	if !overwrite {
+		if _, err := os.Stat(filepath.Join(iologPath, "timing")); !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "%s already holds an I/O log, use -overwrite to replace it\n", iologPath)
+			os.Exit(1)
+		}
+	}
+
+	err := convert.AsciicastToSudo(cast, iologPath, convert.SudoOptions{SkipInput: skipInput})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,76 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wk-y/asciicast2script/convert"
+	"github.com/wk-y/asciicast2script/tlog"
+)
+
+var logPath string
+var overwrite bool
+var skipInput bool
+var metadata tlog.Metadata
+
+func init() {
+	flag.StringVar(&logPath, "log", "-", "output file of tlog JSON messages (- for stdout)")
+	flag.BoolVar(&overwrite, "overwrite", false, "overwrite existing output file")
+	flag.BoolVar(&skipInput, "skip-input", false, "drop input events")
+	flag.StringVar(&metadata.Host, "host", "localhost", "host name of the session")
+	flag.StringVar(&metadata.Rec, "rec", "", "recording ID of the session")
+	flag.StringVar(&metadata.User, "user", "", "user of the session (default from the asciicast's USER)")
+	flag.IntVar(&metadata.Session, "session", 0, "audit session ID")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... ASCIICAST\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	argv := flag.Args()
+	if len(argv) != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	castFile := argv[0]
+
+	cast := os.Stdin
+	if castFile != "-" {
+		var err error
+		cast, err = os.Open(castFile)
+		if err != nil {
+			panic(err)
+		}
+		defer cast.Close()
+	}
+
+	outFlags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		outFlags |= os.O_EXCL
+	}
+
+	log := os.Stdout
+	if logPath != "-" {
+		var err error
+		log, err = os.OpenFile(logPath, outFlags, 0644)
+		if err != nil {
+			panic(err)
+		}
+		defer log.Close()
+	}
+
+	err := convert.AsciicastToTlog(cast, log, metadata, convert.TlogOptions{SkipInput: skipInput})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,68 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wk-y/asciicast2script/convert"
+)
+
+var ttyrecPath string
+var overwrite bool
+
+func init() {
+	flag.StringVar(&ttyrecPath, "ttyrec", "ttyrec", "output ttyrec file (- for stdout)")
+	flag.BoolVar(&overwrite, "overwrite", false, "overwrite existing files")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... ASCIICAST\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	argv := flag.Args()
+	if len(argv) != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	castFile := argv[0]
+
+	cast := os.Stdin
+	if castFile != "-" {
+		var err error
+		cast, err = os.Open(castFile)
+		if err != nil {
+			panic(err)
+		}
+		defer cast.Close()
+	}
+
+	outFlags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		outFlags |= os.O_EXCL
+	}
+
+	recording := os.Stdout
+	if ttyrecPath != "-" {
+		var err error
+		recording, err = os.OpenFile(ttyrecPath, outFlags, 0644)
+		if err != nil {
+			panic(err)
+		}
+		defer recording.Close()
+	}
+
+	err := convert.AsciicastToTtyrec(cast, recording)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,92 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wk-y/asciicast2script/convert"
+	"github.com/wk-y/asciicast2script/internal/castinput"
+)
+
+var gifPath string
+var input castinput.Input
+var overwrite bool
+var idleTimeLimit float64
+var fps float64
+var finalDelay float64
+var speed float64
+var start, end float64
+var scale int
+
+func init() {
+	flag.StringVar(&gifPath, "gif", "-", "output GIF file (- for stdout)")
+	flag.BoolVar(&overwrite, "overwrite", false, "overwrite existing output file")
+	flag.Float64Var(&idleTimeLimit, "idle-time-limit", 0, "longest pause in seconds (default from the asciicast, if any)")
+	flag.Float64Var(&fps, "fps", 15, "highest frame rate, up to 50")
+	flag.Float64Var(&finalDelay, "final-delay", 3, "seconds to show the last frame before starting over")
+	flag.Float64Var(&speed, "speed", 1, "playback speed")
+	flag.Float64Var(&start, "start", 0, "render from this many seconds into the recording")
+	flag.Float64Var(&end, "end", 0, "render up to this many seconds into the recording (default the end)")
+	flag.IntVar(&scale, "scale", 2, "size of the font's pixels in the image")
+	input.RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... [ASCIICAST]\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	argv := flag.Args()
+	if len(argv) > 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var castFile string
+	if len(argv) == 1 {
+		castFile = argv[0]
+	}
+	cast, err := input.Open(castFile)
+	if err != nil {
+		panic(err)
+	}
+	defer cast.Close()
+
+	outFlags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		outFlags |= os.O_EXCL
+	}
+
+	image := os.Stdout
+	if gifPath != "-" {
+		image, err = os.OpenFile(gifPath, outFlags, 0644)
+		if err != nil {
+			panic(err)
+		}
+		defer image.Close()
+	}
+
+	opts := convert.GIFOptions{
+		AnimationOptions: convert.AnimationOptions{
+			IdleTimeLimit: idleTimeLimit,
+			MaxFPS:        fps,
+			FinalDelay:    finalDelay,
+			Speed:         speed,
+			Start:         start,
+			End:           end,
+		},
+		Scale: scale,
+	}
+	err = convert.AsciicastToGIF(cast, image, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
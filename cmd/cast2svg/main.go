@@ -0,0 +1,87 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wk-y/asciicast2script/convert"
+	"github.com/wk-y/asciicast2script/internal/castinput"
+)
+
+var svgPath string
+var input castinput.Input
+var overwrite bool
+var idleTimeLimit float64
+var fps float64
+var finalDelay float64
+var speed float64
+var start, end float64
+
+func init() {
+	flag.StringVar(&svgPath, "svg", "-", "output SVG file (- for stdout)")
+	flag.BoolVar(&overwrite, "overwrite", false, "overwrite existing output file")
+	flag.Float64Var(&idleTimeLimit, "idle-time-limit", 0, "longest pause in seconds (default from the asciicast, if any)")
+	flag.Float64Var(&fps, "fps", 30, "highest frame rate (0 for no limit)")
+	flag.Float64Var(&finalDelay, "final-delay", 3, "seconds to show the last frame before starting over")
+	flag.Float64Var(&speed, "speed", 1, "playback speed")
+	flag.Float64Var(&start, "start", 0, "render from this many seconds into the recording")
+	flag.Float64Var(&end, "end", 0, "render up to this many seconds into the recording (default the end)")
+	input.RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... [ASCIICAST]\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	argv := flag.Args()
+	if len(argv) > 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var castFile string
+	if len(argv) == 1 {
+		castFile = argv[0]
+	}
+	cast, err := input.Open(castFile)
+	if err != nil {
+		panic(err)
+	}
+	defer cast.Close()
+
+	outFlags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		outFlags |= os.O_EXCL
+	}
+
+	svg := os.Stdout
+	if svgPath != "-" {
+		svg, err = os.OpenFile(svgPath, outFlags, 0644)
+		if err != nil {
+			panic(err)
+		}
+		defer svg.Close()
+	}
+
+	opts := convert.AnimationOptions{
+		IdleTimeLimit: idleTimeLimit,
+		MaxFPS:        fps,
+		FinalDelay:    finalDelay,
+		Speed:         speed,
+		Start:         start,
+		End:           end,
+	}
+	err = convert.AsciicastToSVG(cast, svg, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,70 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wk-y/asciicast2script/convert"
+	"github.com/wk-y/asciicast2script/internal/castinput"
+)
+
+var txtPath string
+var input castinput.Input
+var overwrite bool
+var unwrap bool
+
+func init() {
+	flag.StringVar(&txtPath, "txt", "-", "output text file (- for stdout)")
+	flag.BoolVar(&overwrite, "overwrite", false, "overwrite existing output file")
+	flag.BoolVar(&unwrap, "unwrap", false, "join lines which wrapped at the right margin")
+	input.RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... [ASCIICAST]\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	argv := flag.Args()
+	if len(argv) > 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var castFile string
+	if len(argv) == 1 {
+		castFile = argv[0]
+	}
+	cast, err := input.Open(castFile)
+	if err != nil {
+		panic(err)
+	}
+	defer cast.Close()
+
+	outFlags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		outFlags |= os.O_EXCL
+	}
+
+	text := os.Stdout
+	if txtPath != "-" {
+		text, err = os.OpenFile(txtPath, outFlags, 0644)
+		if err != nil {
+			panic(err)
+		}
+		defer text.Close()
+	}
+
+	err = convert.AsciicastToText(cast, text, convert.TextOptions{Unwrap: unwrap})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -5,27 +5,43 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
-	"github.com/wk-y/asciicast2script/asciicast"
-	"github.com/wk-y/asciicast2script/script"
+	"github.com/wk-y/asciicast2script/convert"
+	"github.com/wk-y/asciicast2script/internal/castwarn"
 )
 
 var typescriptPath string
+var inputPath string
 var timingfilePath string
 var overwrite bool
 var v3 bool // write asciicast v3
+var skipInput bool
+var width, height int
+var charset string
+var escapeBytes bool
+var bsd bool
+var timezone string
+var holdHeader bool
 
 func init() {
-	flag.StringVar(&typescriptPath, "typescript", "typescript", "input typescript file")
+	flag.StringVar(&typescriptPath, "typescript", "typescript", "input typescript file (output log when -log-in is used)")
+	flag.StringVar(&inputPath, "log-in", "", "separate input log file, as written by script --log-in")
 	flag.StringVar(&timingfilePath, "timingfile", "timingfile", "input timing file")
 	flag.BoolVar(&overwrite, "overwrite", false, "overwrite existing output file")
 	flag.BoolVar(&v3, "v3", false, "use asciicast v3 format")
+	flag.BoolVar(&skipInput, "skip-input", false, "drop input events")
+	flag.IntVar(&width, "cols", 80, "terminal width if the typescript doesn't record it")
+	flag.IntVar(&height, "rows", 24, "terminal height if the typescript doesn't record it")
+	flag.BoolVar(&bsd, "bsd", false, "read -typescript as a BSD/macOS script -r recording (no timing file)")
+	flag.StringVar(&charset, "charset", "", "character set of the typescript and input log, ex. ISO-8859-1 or Shift_JIS (default UTF-8)")
+	flag.StringVar(&timezone, "timezone", "UTC", "time zone of older typescripts, which don't record it, ex. Europe/Berlin")
+	flag.BoolVar(&holdHeader, "hold-header", false, "write the v2 header at the end with the exit status, holding the events in memory")
+	flag.BoolVar(&escapeBytes, "escape-bytes", false, "keep bytes which aren't valid UTF-8 as escapes, so they survive conversion back")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... OUTFILE.cast\n\n", os.Args[0])
 		flag.PrintDefaults()
@@ -43,6 +59,12 @@ func main() {
 
 	castFile := argv[0]
 
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	outFlags := os.O_WRONLY | os.O_CREATE
 	if !overwrite {
 		outFlags |= os.O_EXCL
@@ -50,7 +72,7 @@ func main() {
 		outFlags |= os.O_TRUNC
 	}
 
-	cast := os.Stdin
+	cast := os.Stdout
 	if castFile != "-" {
 		var err error
 		cast, err = os.OpenFile(castFile, outFlags, 0644)
@@ -60,172 +82,66 @@ func main() {
 		defer cast.Close()
 	}
 
-	script, err := os.Open(typescriptPath)
-	if err != nil {
-		panic(err)
-	}
-	defer script.Close()
-
-	timing, err := os.Open(timingfilePath)
-	if err != nil {
-		panic(err)
-	}
-	defer timing.Close()
-
-	converter := scriptToAsciicast
-	if v3 {
-		converter = scriptToAsciicastV3
-	}
-
-	err = converter(script, bufio.NewReader(timing), cast)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
-}
-
-func scriptToAsciicast(typescript io.Reader, timingfile *bufio.Reader, cast io.Writer) error {
-	encoder := json.NewEncoder(cast)
-	tsBuffered := bufio.NewReader(typescript)
-
-	headerBytes, err := tsBuffered.ReadBytes('\n')
-	if err != nil {
-		return err
-	}
-
-	header, err := script.ParseHeader(string(headerBytes))
-	if err != nil {
-		return err
-	}
-
-	timestamp := header.Start.Unix()
-	env := map[string]string{}
-	if header.Term != "" {
-		env["TERM"] = header.Term
+	var typescript, input io.Reader
+	if typescriptPath != "" {
+		file, err := os.Open(typescriptPath)
+		if err != nil {
+			panic(err)
+		}
+		defer file.Close()
+		typescript = file
 	}
 
-	acHeader := asciicast.HeaderV2{
-		Version:   2,
-		Width:     header.Columns,
-		Height:    header.Lines,
-		Timestamp: &timestamp,
-		Env:       env,
+	if inputPath != "" {
+		file, err := os.Open(inputPath)
+		if err != nil {
+			panic(err)
+		}
+		defer file.Close()
+		input = file
 	}
 
-	if header.Command != "" {
-		acHeader.Command = &header.Command
+	opts := convert.AsciicastOptions{
+		Version:     2,
+		SkipInput:   skipInput,
+		Width:       width,
+		Height:      height,
+		Charset:     charset,
+		EscapeBytes: escapeBytes,
+		Location:    location,
+		HoldHeader:  holdHeader,
 	}
-
-	if err := encoder.Encode(acHeader); err != nil {
-		return err
+	opts.OnReplacedBytes = castwarn.ReplacedBytes(os.Stderr)
+	if v3 {
+		opts.Version = 3
 	}
 
-	var sEvent script.Event
-	var time float64
-	for {
-		if err := sEvent.Take(tsBuffered, timingfile); err != nil {
-			if err == io.EOF {
-				return nil
-			}
-			return err
+	if bsd {
+		if typescript == nil {
+			fmt.Fprintln(os.Stderr, "-bsd requires -typescript")
+			os.Exit(1)
 		}
-
-		time += sEvent.ElapsedSeconds
-
-		acEvent := asciicast.Event{
-			Time: time,
-			Data: sEvent.Data,
-		}
-
-		var ignore bool
-		switch sEvent.Code {
-		case 'I':
-			acEvent.Code = "i"
-		case 'O':
-			acEvent.Code = "o"
-		default:
-			ignore = true
-		}
-
-		if ignore {
-			continue
+		if input != nil {
+			fmt.Fprintln(os.Stderr, "-bsd can not be used with -log-in")
+			os.Exit(1)
 		}
 
-		if err := encoder.Encode(acEvent); err != nil {
-			return err
+		if err := convert.BSDToAsciicast(typescript, cast, opts); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
+		return
 	}
-}
-
-func scriptToAsciicastV3(typescript io.Reader, timingfile *bufio.Reader, cast io.Writer) error {
-	encoder := json.NewEncoder(cast)
-	tsBuffered := bufio.NewReader(typescript)
 
-	headerBytes, err := tsBuffered.ReadBytes('\n')
+	timing, err := os.Open(timingfilePath)
 	if err != nil {
-		return err
+		panic(err)
 	}
+	defer timing.Close()
 
-	header, err := script.ParseHeader(string(headerBytes))
+	err = convert.ScriptStreamsToAsciicast(typescript, input, timing, cast, opts)
 	if err != nil {
-		return err
-	}
-
-	timestamp := header.Start.Unix()
-	env := map[string]string{}
-	if header.Term != "" {
-		env["TERM"] = header.Term
-	}
-
-	acHeader := asciicast.HeaderV3{
-		Version: 3,
-		Term: asciicast.TermInfo{
-			Cols: header.Columns,
-			Rows: header.Lines,
-			Type: &header.Term,
-		},
-		Timestamp: &timestamp,
-		Env:       env,
-	}
-
-	if header.Command != "" {
-		acHeader.Command = &header.Command
-	}
-
-	if err := encoder.Encode(acHeader); err != nil {
-		return err
-	}
-
-	var sEvent script.Event
-	for {
-		if err := sEvent.Take(tsBuffered, timingfile); err != nil {
-			if err == io.EOF {
-				return nil
-			}
-			return err
-		}
-
-		acEvent := asciicast.Event{
-			Time: sEvent.ElapsedSeconds, // asciicast v3 uses relative time
-			Data: sEvent.Data,
-		}
-
-		var ignore bool
-		switch sEvent.Code {
-		case 'I':
-			acEvent.Code = "i"
-		case 'O':
-			acEvent.Code = "o"
-		default:
-			ignore = true
-		}
-
-		if ignore {
-			continue
-		}
-
-		if err := encoder.Encode(acEvent); err != nil {
-			return err
-		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
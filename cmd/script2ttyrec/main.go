@@ -0,0 +1,80 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/wk-y/asciicast2script/convert"
+)
+
+var typescriptPath string
+var timingfilePath string
+var ttyrecPath string
+var overwrite bool
+var timezone string
+
+func init() {
+	flag.StringVar(&typescriptPath, "typescript", "typescript", "input typescript file")
+	flag.StringVar(&timingfilePath, "timingfile", "timingfile", "input timing file")
+	flag.BoolVar(&overwrite, "overwrite", false, "overwrite existing output file")
+	flag.StringVar(&timezone, "timezone", "UTC", "time zone of older typescripts, which don't record it, ex. Europe/Berlin")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... OUTFILE.ttyrec\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	argv := flag.Args()
+	if len(argv) != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	ttyrecPath = argv[0]
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	typescript, err := os.Open(typescriptPath)
+	if err != nil {
+		panic(err)
+	}
+	defer typescript.Close()
+
+	timing, err := os.Open(timingfilePath)
+	if err != nil {
+		panic(err)
+	}
+	defer timing.Close()
+
+	outFlags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		outFlags |= os.O_EXCL
+	}
+
+	recording := os.Stdout
+	if ttyrecPath != "-" {
+		recording, err = os.OpenFile(ttyrecPath, outFlags, 0644)
+		if err != nil {
+			panic(err)
+		}
+		defer recording.Close()
+	}
+
+	if err := convert.ScriptToTtyrec(typescript, timing, recording, convert.AsciicastOptions{Location: location}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
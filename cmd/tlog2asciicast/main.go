@@ -0,0 +1,119 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wk-y/asciicast2script/convert"
+	"github.com/wk-y/asciicast2script/internal/castwarn"
+	"github.com/wk-y/asciicast2script/tlog"
+)
+
+var logPath string
+var rec string
+var overwrite bool
+var v3 bool // write asciicast v3
+var skipInput bool
+var width, height int
+var escapeBytes bool
+
+func init() {
+	flag.StringVar(&logPath, "log", "-", "input file of tlog JSON messages (- for stdin)")
+	flag.StringVar(&rec, "rec", "", "recording ID of the session to convert, if the log holds several")
+	flag.BoolVar(&overwrite, "overwrite", false, "overwrite existing output file")
+	flag.BoolVar(&v3, "v3", false, "use asciicast v3 format")
+	flag.BoolVar(&skipInput, "skip-input", false, "drop input events")
+	flag.IntVar(&width, "cols", 80, "terminal width if the session doesn't record it")
+	flag.IntVar(&height, "rows", 24, "terminal height if the session doesn't record it")
+	flag.BoolVar(&escapeBytes, "escape-bytes", false, "keep bytes which aren't valid UTF-8 as escapes, so they survive conversion back")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... OUTFILE.cast\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	argv := flag.Args()
+	if len(argv) != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	castFile := argv[0]
+
+	log := os.Stdin
+	if logPath != "-" {
+		var err error
+		log, err = os.Open(logPath)
+		if err != nil {
+			panic(err)
+		}
+		defer log.Close()
+	}
+
+	sessions, err := tlog.ReadSessions(log)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var session *tlog.Session
+	for i := range sessions {
+		if rec == "" || sessions[i].Rec == rec {
+			if session != nil {
+				fmt.Fprintln(os.Stderr, "the log holds several recordings, select one with -rec:")
+				for _, s := range sessions {
+					fmt.Fprintf(os.Stderr, "  %s (user %s on %s)\n", s.Rec, s.User, s.Host)
+				}
+				os.Exit(1)
+			}
+			session = &sessions[i]
+		}
+	}
+	if session == nil {
+		fmt.Fprintln(os.Stderr, "recording not found")
+		os.Exit(1)
+	}
+
+	outFlags := os.O_WRONLY | os.O_CREATE
+	if !overwrite {
+		outFlags |= os.O_EXCL
+	} else {
+		outFlags |= os.O_TRUNC
+	}
+
+	cast := os.Stdout
+	if castFile != "-" {
+		var err error
+		cast, err = os.OpenFile(castFile, outFlags, 0644)
+		if err != nil {
+			panic(err)
+		}
+		defer cast.Close()
+	}
+
+	opts := convert.AsciicastOptions{
+		Version:     2,
+		SkipInput:   skipInput,
+		Width:       width,
+		Height:      height,
+		EscapeBytes: escapeBytes,
+	}
+	opts.OnReplacedBytes = castwarn.ReplacedBytes(os.Stderr)
+	if v3 {
+		opts.Version = 3
+	}
+
+	err = convert.TlogSessionToAsciicast(*session, cast, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,92 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wk-y/asciicast2script/convert"
+	"github.com/wk-y/asciicast2script/internal/castwarn"
+)
+
+var ttyrecPath string
+var overwrite bool
+var v3 bool // write asciicast v3
+var width, height int
+var charset string
+var escapeBytes bool
+
+func init() {
+	flag.StringVar(&ttyrecPath, "ttyrec", "ttyrec", "input ttyrec file (- for stdin)")
+	flag.BoolVar(&overwrite, "overwrite", false, "overwrite existing output file")
+	flag.BoolVar(&v3, "v3", false, "use asciicast v3 format")
+	flag.IntVar(&width, "cols", 80, "terminal width")
+	flag.IntVar(&height, "rows", 24, "terminal height")
+	flag.StringVar(&charset, "charset", "", "character set of the recorded output, ex. EUC-JP (default UTF-8)")
+	flag.BoolVar(&escapeBytes, "escape-bytes", false, "keep bytes which aren't valid UTF-8 as escapes, so they survive conversion back")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... OUTFILE.cast\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	argv := flag.Args()
+	if len(argv) != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	castFile := argv[0]
+
+	outFlags := os.O_WRONLY | os.O_CREATE
+	if !overwrite {
+		outFlags |= os.O_EXCL
+	} else {
+		outFlags |= os.O_TRUNC
+	}
+
+	cast := os.Stdout
+	if castFile != "-" {
+		var err error
+		cast, err = os.OpenFile(castFile, outFlags, 0644)
+		if err != nil {
+			panic(err)
+		}
+		defer cast.Close()
+	}
+
+	recording := os.Stdin
+	if ttyrecPath != "-" {
+		var err error
+		recording, err = os.Open(ttyrecPath)
+		if err != nil {
+			panic(err)
+		}
+		defer recording.Close()
+	}
+
+	opts := convert.AsciicastOptions{
+		Version:     2,
+		Width:       width,
+		Height:      height,
+		Charset:     charset,
+		EscapeBytes: escapeBytes,
+	}
+	opts.OnReplacedBytes = castwarn.ReplacedBytes(os.Stderr)
+	if v3 {
+		opts.Version = 3
+	}
+
+	err := convert.TtyrecToAsciicast(recording, cast, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
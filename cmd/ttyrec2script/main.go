@@ -0,0 +1,91 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wk-y/asciicast2script/convert"
+)
+
+var ttyrecPath string
+var typescriptPath string
+var timingfilePath string
+var overwrite bool
+var timingFormat string
+var width, height int
+
+func init() {
+	flag.StringVar(&typescriptPath, "typescript", "typescript", "output typescript file")
+	flag.StringVar(&timingfilePath, "timingfile", "timingfile", "output timing file")
+	flag.BoolVar(&overwrite, "overwrite", false, "overwrite existing files")
+	flag.StringVar(&timingFormat, "format", "advanced", "timing file format: advanced or classic")
+	flag.IntVar(&width, "cols", 80, "terminal width")
+	flag.IntVar(&height, "rows", 24, "terminal height")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... TTYREC\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	argv := flag.Args()
+	if len(argv) != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	ttyrecPath = argv[0]
+
+	var classic bool
+	switch timingFormat {
+	case "advanced":
+	case "classic":
+		classic = true
+	default:
+		fmt.Fprintf(os.Stderr, "unknown timing format %q\n", timingFormat)
+		os.Exit(1)
+	}
+
+	recording := os.Stdin
+	if ttyrecPath != "-" {
+		var err error
+		recording, err = os.Open(ttyrecPath)
+		if err != nil {
+			panic(err)
+		}
+		defer recording.Close()
+	}
+
+	outFlags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		outFlags |= os.O_EXCL
+	}
+
+	typescript, err := os.OpenFile(typescriptPath, outFlags, 0644)
+	if err != nil {
+		panic(err)
+	}
+	defer typescript.Close()
+
+	timing, err := os.OpenFile(timingfilePath, outFlags, 0644)
+	if err != nil {
+		panic(err)
+	}
+	defer timing.Close()
+
+	err = convert.TtyrecToScript(recording, typescript, timing, convert.AsciicastOptions{
+		Width:  width,
+		Height: height,
+	}, convert.ScriptOptions{Classic: classic})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
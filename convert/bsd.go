@@ -0,0 +1,164 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/bsdscript"
+)
+
+// BSDToAsciicast converts a recording made by BSD "script -r" into an asciicast.
+//
+// The recording has no terminal size, so the size from opts is used.
+// Characters split between records are joined in the later event.
+func BSDToAsciicast(recording io.Reader, cast io.Writer, opts AsciicastOptions) error {
+	// The first record, normally the start record, gives the start time
+	var first bsdscript.Event
+	err := first.Take(recording)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	empty := err == io.EOF
+
+	encoder, err := newDataEncoder(opts)
+	if err != nil {
+		return err
+	}
+
+	start := first.Time
+	header := asciicast.HeaderV2{
+		Version: 2,
+		Width:   opts.width(),
+		Height:  opts.height(),
+	}
+	if !empty {
+		timestamp := start.Unix()
+		header.Timestamp = &timestamp
+	}
+
+	writer, err := asciicast.NewWriter(cast, opts.version(), encoder.header(asciicast.HeaderV2Iface{Header: header}))
+	if err != nil || empty {
+		return err
+	}
+
+	var t float64
+	for event := first; ; {
+		t = event.Time.Sub(start).Seconds()
+		acEvent := asciicast.Event{
+			Time: t,
+			Data: event.Data,
+		}
+
+		var ignore bool
+		switch event.Direction {
+		case bsdscript.InputDirection:
+			acEvent.Code = asciicast.InputEvent
+			ignore = opts.SkipInput
+		case bsdscript.OutputDirection:
+			acEvent.Code = asciicast.OutputEvent
+		default:
+			ignore = true
+		}
+
+		if !ignore {
+			acEvent, ok, err := encoder.encode(acEvent)
+			if err != nil {
+				return err
+			}
+			if ok {
+				if err := writer.Write(acEvent); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := event.Take(recording); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+	}
+
+	rest, err := encoder.flush(t)
+	if err != nil {
+		return err
+	}
+	for _, acEvent := range rest {
+		if err := writer.Write(acEvent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AsciicastToBSD converts an asciicast into a recording for BSD "script -p".
+//
+// Only input and output events are kept. The recording is written in little-endian byte order.
+func AsciicastToBSD(cast io.Reader, recording io.Writer, opts ScriptOptions) error {
+	reader, err := asciicast.NewReader(cast)
+	if err != nil {
+		return err
+	}
+
+	var start time.Time
+	if timestamp, ok := reader.Header().Timestamp(); ok {
+		start = time.Unix(timestamp, 0).UTC()
+	} else {
+		start = time.Unix(0, 0)
+	}
+
+	unescape := byteUnescaper(reader.Header())
+
+	write := func(event bsdscript.Event) error {
+		return event.Write(recording, binary.LittleEndian)
+	}
+
+	if err := write(bsdscript.Event{Time: start, Direction: bsdscript.StartDirection}); err != nil {
+		return err
+	}
+
+	var finalTime float64
+	for {
+		acEvent, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		finalTime = max(finalTime, acEvent.Time)
+
+		event := bsdscript.Event{
+			Data: unescape(acEvent.Data),
+			Time: start.Add(time.Duration(acEvent.Time * float64(time.Second))),
+		}
+
+		switch acEvent.Code {
+		case asciicast.InputEvent:
+			if opts.SkipInput {
+				continue
+			}
+			event.Direction = bsdscript.InputDirection
+		case asciicast.OutputEvent:
+			event.Direction = bsdscript.OutputDirection
+		default:
+			continue
+		}
+
+		if err := write(event); err != nil {
+			return err
+		}
+	}
+
+	end := start.Add(time.Duration(finalTime * float64(time.Second)))
+	return write(bsdscript.Event{Time: end, Direction: bsdscript.EndDirection})
+}
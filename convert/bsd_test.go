@@ -0,0 +1,54 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+)
+
+func TestBSDRoundtrip(t *testing.T) {
+	cast := `{"version": 2, "width": 80, "height": 24, "timestamp": 1743536096}` + "\n" +
+		`[0.5, "o", "hello"]` + "\n" +
+		`[1.25, "i", "x"]` + "\n"
+
+	var recording bytes.Buffer
+	if err := AsciicastToBSD(strings.NewReader(cast), &recording, ScriptOptions{}); err != nil {
+		t.Fatalf("Error converting to BSD recording: %v", err)
+	}
+
+	var castOut bytes.Buffer
+	if err := BSDToAsciicast(&recording, &castOut, AsciicastOptions{}); err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	if castOut.String() != `{"version":2,"width":80,"height":24,"timestamp":1743536096,"duration":null,"command":null,"title":null,"idle_time_limit":null,"env":null,"theme":null}`+"\n"+
+		`[0.5,"o","hello"]`+"\n"+
+		`[1.25,"i","x"]`+"\n" {
+		t.Errorf("Wrong asciicast:\n%s", castOut.String())
+	}
+}
+
+func TestEmptyBSD(t *testing.T) {
+	var cast bytes.Buffer
+	if err := BSDToAsciicast(strings.NewReader(""), &cast, AsciicastOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	reader, err := asciicast.NewReader(&cast)
+	if err != nil {
+		t.Fatalf("Error reading asciicast: %v", err)
+	}
+	if timestamp, ok := reader.Header().Timestamp(); ok {
+		t.Errorf("Timestamp %d for an empty recording", timestamp)
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("Expected no events, got %v", err)
+	}
+}
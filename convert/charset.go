@@ -0,0 +1,181 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Asciicast header field marking event data escaped with asciicast.EscapeBytes
+const escapedBytesKey = "escaped_bytes"
+
+// dataEncoder converts the data of input and output events to UTF-8 for an asciicast.
+type dataEncoder struct {
+	charset  encoding.Encoding // nil for UTF-8
+	escape   bool
+	decoders map[asciicast.EventCode]transform.Transformer
+	carry    map[asciicast.EventCode][]byte // incomplete character at the end of the last event
+	replaced func()                         // called on the first invalid byte, then nil
+}
+
+func newDataEncoder(opts AsciicastOptions) (*dataEncoder, error) {
+	e := &dataEncoder{
+		escape:   opts.EscapeBytes,
+		replaced: opts.OnReplacedBytes,
+		decoders: map[asciicast.EventCode]transform.Transformer{},
+		carry:    map[asciicast.EventCode][]byte{},
+	}
+
+	if opts.Charset != "" {
+		charset, err := ianaindex.IANA.Encoding(opts.Charset)
+		if err != nil || charset == nil {
+			return nil, fmt.Errorf("unsupported charset %q", opts.Charset)
+		}
+		if charset != unicode.UTF8 {
+			e.charset = charset
+		}
+	}
+
+	return e, nil
+}
+
+// Mark the header if the data is escaped
+func (e *dataEncoder) header(header asciicast.Header) asciicast.Header {
+	if !e.escape {
+		return header
+	}
+
+	v2 := asciicast.ToHeaderV2(header)
+	if v2.Extra == nil {
+		v2.Extra = map[string]json.RawMessage{}
+	}
+	v2.Extra[escapedBytesKey] = json.RawMessage("true")
+	return asciicast.HeaderV2Iface{Header: v2}
+}
+
+// Convert the data of an input or output event.
+//
+// The bytes of a character split between events are joined in the next event
+// with the same code, so ok is false if none of the data is left to write.
+func (e *dataEncoder) encode(event asciicast.Event) (result asciicast.Event, ok bool, err error) {
+	if event.Code != asciicast.InputEvent && event.Code != asciicast.OutputEvent {
+		return event, true, nil
+	}
+
+	if e.charset != nil {
+		if event.Data, err = e.decode(event.Code, event.Data, false); err != nil {
+			return event, false, err
+		}
+	} else {
+		data := string(e.carry[event.Code]) + event.Data
+		split := incompleteSuffix(data)
+		event.Data, e.carry[event.Code] = data[:split], []byte(data[split:])
+	}
+
+	if event.Data == "" {
+		return event, false, nil
+	}
+	event.Data = e.finish(event.Data)
+	return event, true, nil
+}
+
+// Return the incomplete characters left over at the end of the recording as events
+func (e *dataEncoder) flush(time float64) ([]asciicast.Event, error) {
+	var events []asciicast.Event
+	for _, code := range []asciicast.EventCode{asciicast.OutputEvent, asciicast.InputEvent} {
+		if len(e.carry[code]) == 0 {
+			continue
+		}
+
+		data := string(e.carry[code])
+		e.carry[code] = nil
+		if e.charset != nil {
+			var err error
+			if data, err = e.decode(code, data, true); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, asciicast.Event{Time: time, Code: code, Data: e.finish(data)})
+	}
+	return events, nil
+}
+
+// Escape decoded data, or report it if it isn't valid UTF-8
+func (e *dataEncoder) finish(data string) string {
+	if e.escape {
+		return asciicast.EscapeBytes(data)
+	}
+	if e.replaced != nil && !utf8.ValidString(data) {
+		e.replaced()
+		e.replaced = nil
+	}
+	return data
+}
+
+// Decode data from the charset, keeping an incomplete character at the end for the next call
+func (e *dataEncoder) decode(code asciicast.EventCode, data string, atEOF bool) (string, error) {
+	decoder, ok := e.decoders[code]
+	if !ok {
+		decoder = e.charset.NewDecoder()
+		e.decoders[code] = decoder
+	}
+
+	src := append(e.carry[code], data...)
+	e.carry[code] = nil
+
+	var result []byte
+	buf := make([]byte, 4*len(src)+16)
+	for {
+		nDst, nSrc, err := decoder.Transform(buf, src, atEOF)
+		result = append(result, buf[:nDst]...)
+		src = src[nSrc:]
+
+		switch err {
+		case nil:
+			return string(result), nil
+		case transform.ErrShortDst:
+			if nDst == 0 {
+				buf = make([]byte, 2*len(buf))
+			}
+		case transform.ErrShortSrc:
+			e.carry[code] = src
+			return string(result), nil
+		default:
+			return string(result), err
+		}
+	}
+}
+
+// byteUnescaper returns the function restoring the data of events of an asciicast
+// written with AsciicastOptions.EscapeBytes.
+func byteUnescaper(header asciicast.Header) func(string) string {
+	var escaped bool
+	if raw, ok := header.Extra()[escapedBytesKey]; ok && json.Unmarshal(raw, &escaped) == nil && escaped {
+		return asciicast.UnescapeBytes
+	}
+	return func(data string) string { return data }
+}
+
+// incompleteSuffix returns the index of the start of an incomplete UTF-8 character
+// at the end of s, or len(s) if there is none.
+func incompleteSuffix(s string) int {
+	for i := len(s) - 1; i >= 0 && i >= len(s)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(s[i]) {
+			if !utf8.FullRuneInString(s[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(s)
+}
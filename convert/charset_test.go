@@ -0,0 +1,113 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/bsdscript"
+	"github.com/wk-y/asciicast2script/ttyrec"
+)
+
+func TestSplitCharacter(t *testing.T) {
+	// "─" (U+2500) split between two chunks of output
+	chunks := []string{"a\xe2\x94", "\x80b"}
+	start := time.Unix(1743536096, 0)
+
+	for _, test := range []struct {
+		name    string
+		convert func(cast io.Writer, opts AsciicastOptions) error
+	}{
+		{"script", func(cast io.Writer, opts AsciicastOptions) error {
+			typescript := "Script started on Tue Apr  1 12:34:56 2025\n" + strings.Join(chunks, "")
+			timingfile := "O 0.500000 3\nO 0.500000 2\n"
+			return ScriptToAsciicast(strings.NewReader(typescript), strings.NewReader(timingfile), cast, opts)
+		}},
+		{"BSD", func(cast io.Writer, opts AsciicastOptions) error {
+			var recording bytes.Buffer
+			events := []bsdscript.Event{{Time: start, Direction: bsdscript.StartDirection}}
+			for i, chunk := range chunks {
+				events = append(events, bsdscript.Event{Time: start.Add(time.Duration(i+1) * time.Second), Direction: bsdscript.OutputDirection, Data: chunk})
+			}
+			for _, event := range events {
+				if err := event.Write(&recording, binary.LittleEndian); err != nil {
+					return err
+				}
+			}
+			return BSDToAsciicast(&recording, cast, opts)
+		}},
+		{"ttyrec", func(cast io.Writer, opts AsciicastOptions) error {
+			var recording bytes.Buffer
+			for i, chunk := range chunks {
+				event := ttyrec.Event{Time: start.Add(time.Duration(i) * time.Second), Data: chunk}
+				if err := event.Write(&recording); err != nil {
+					return err
+				}
+			}
+			return TtyrecToAsciicast(&recording, cast, opts)
+		}},
+		{"sudo", func(cast io.Writer, opts AsciicastOptions) error {
+			fsys := fstest.MapFS{
+				"log":    {Data: []byte("1743536096:alice:root::/dev/pts/1\n/\n/bin/sh\n")},
+				"timing": {Data: []byte("4 0.500000 3\n4 0.500000 2\n")},
+				"ttyout": {Data: []byte(strings.Join(chunks, ""))},
+			}
+			return SudoToAsciicast(fsys, cast, opts)
+		}},
+	} {
+		for _, escape := range []bool{false, true} {
+			var cast bytes.Buffer
+			if err := test.convert(&cast, AsciicastOptions{EscapeBytes: escape}); err != nil {
+				t.Fatalf("%s: Error converting to asciicast: %v", test.name, err)
+			}
+
+			reader, err := asciicast.NewReader(&cast)
+			if err != nil {
+				t.Fatalf("%s: Error reading asciicast: %v", test.name, err)
+			}
+			var data []string
+			for {
+				event, err := reader.Next()
+				if err != nil {
+					if err == io.EOF {
+						break
+					}
+					t.Fatalf("%s: Error reading event: %v", test.name, err)
+				}
+				data = append(data, event.Data)
+			}
+
+			if strings.Join(data, "|") != "a|─b" {
+				t.Errorf("%s (escape %v): Wrong output %q", test.name, escape, data)
+			}
+		}
+	}
+}
+
+func TestIncompleteSuffix(t *testing.T) {
+	for _, test := range []struct {
+		s        string
+		expected int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"ab\xe2\x94", 2},
+		{"ab\xe2\x94\x80", 5},
+		{"\xf0\x9f\x98", 0},
+		{"a\xff", 2},     // not the start of a character
+		{"a\x80\x80", 3}, // continuation bytes without a start
+	} {
+		if actual := incompleteSuffix(test.s); actual != test.expected {
+			t.Errorf("incompleteSuffix(%q) = %d, expected %d", test.s, actual, test.expected)
+		}
+	}
+}
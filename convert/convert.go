@@ -0,0 +1,130 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package convert converts between asciicasts and script's typescript/timingfile.
+package convert
+
+import (
+	"cmp"
+	"time"
+
+	"github.com/wk-y/asciicast2script/script"
+)
+
+// AsciicastOptions controls conversion to asciicast.
+type AsciicastOptions struct {
+	Version   int  // asciicast version to write, defaults to 2
+	SkipInput bool // drop input events
+
+	// Terminal size for recordings that don't record one, such as
+	// typescripts from BSD script. Defaults to 80x24.
+	Width  int
+	Height int
+
+	// Character set of the recording's input and output, by IANA name
+	// (ex. "ISO-8859-1", "Shift_JIS"). The data is converted to UTF-8.
+	// Defaults to UTF-8. Tlog recordings are always UTF-8, so it must not be set for them.
+	Charset string
+
+	// Keep bytes which aren't valid UTF-8 with asciicast.EscapeBytes, instead of
+	// letting them become U+FFFD. The asciicast is marked with an "escaped_bytes"
+	// header field, and the bytes are restored when converting it back.
+	EscapeBytes bool
+
+	// Called once if bytes which aren't valid UTF-8 are replaced with U+FFFD,
+	// so converting the asciicast back won't reproduce the recording exactly.
+	// It isn't called with EscapeBytes, which keeps such bytes.
+	OnReplacedBytes func()
+
+	// Write v2 asciicasts converted from script once the whole recording has
+	// been read, so the header has the exit status and the info entries at the
+	// end of the timingfile. The events are held in memory until then.
+	HoldHeader bool
+
+	// Time zone of typescript start dates which don't record one. Defaults to UTC.
+	Location *time.Location
+
+	// Dialects for typescript header lines of other variants of script,
+	// tried before the built-in ones.
+	HeaderDialects []script.HeaderDialect
+}
+
+func (o AsciicastOptions) version() int {
+	return cmp.Or(o.Version, 2)
+}
+
+func (o AsciicastOptions) width() int {
+	return cmp.Or(o.Width, 80)
+}
+
+func (o AsciicastOptions) height() int {
+	return cmp.Or(o.Height, 24)
+}
+
+// ScriptOptions controls conversion to script.
+type ScriptOptions struct {
+	SkipInput bool // drop input events
+
+	// Write the classic timing format, which only records output.
+	// Conversion fails with script.ErrClassicInput if the asciicast has
+	// input events, unless SkipInput is set.
+	Classic bool
+}
+
+// SudoOptions controls conversion to sudo I/O logs.
+type SudoOptions struct {
+	SkipInput bool // drop input events
+}
+
+// TlogOptions controls conversion to tlog.
+type TlogOptions struct {
+	SkipInput bool // drop input events
+}
+
+// TextOptions controls conversion to text.
+type TextOptions struct {
+	// Join lines which wrapped at the right margin, so long lines stay whole
+	Unwrap bool
+}
+
+// AnimationOptions controls the timing of animations rendered from asciicasts.
+type AnimationOptions struct {
+	// Longest pause between frames in seconds, so idle periods don't drag on.
+	// Defaults to the asciicast's idle_time_limit, if any.
+	IdleTimeLimit float64
+
+	// Highest number of frames per second. Output closer together than a frame
+	// is shown at once. 0 for no limit.
+	MaxFPS float64
+
+	// Seconds the last frame is shown before the animation starts over
+	FinalDelay float64
+
+	// Playback speed, where 2 is twice as fast. Defaults to 1.
+	Speed float64
+
+	// Part of the recording to render, in seconds from its start. Output before
+	// Start is shown in the first frame. End defaults to the end of the recording.
+	Start float64
+	End   float64
+}
+
+func (o AnimationOptions) speed() float64 {
+	if o.Speed <= 0 {
+		return 1
+	}
+	return o.Speed
+}
+
+// GIFOptions controls conversion to GIF.
+type GIFOptions struct {
+	AnimationOptions
+
+	// Size of a font pixel in the image, in pixels. Defaults to 2.
+	Scale int
+}
+
+func (o GIFOptions) scale() int {
+	return cmp.Or(max(o.Scale, 0), 2)
+}
@@ -0,0 +1,711 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/script"
+)
+
+const testTypescript = `Script started on 2025-04-01 12:34:56-07:00 [TERM="xterm-256color" TTY="/dev/pts/2" COLUMNS="80" LINES="24"]` + "\n" +
+	"hello worldls\n"
+
+const testTimingfile = "H 0.000000 SHELL /bin/bash\n" +
+	"H 0.000000 TIMING_LOG timingfile\n" +
+	"O 0.500000 5\n" +
+	"S 0.100000 SIGWINCH ROWS=30 COLS=100\n" +
+	"O 0.250000 7\n" +
+	"I 1.000000 2\n"
+
+// testTimingfile after a round trip, with the info entries derived from the header
+const testRoundtripTimingfile = "H 0.000000 START_TIME 2025-04-01 19:34:56+00:00\n" +
+	"H 0.000000 TERM xterm-256color\n" +
+	"H 0.000000 TTY /dev/pts/2\n" +
+	"H 0.000000 COLUMNS 80\n" +
+	"H 0.000000 LINES 24\n" +
+	testTimingfile +
+	"H 0.000000 DURATION 1.850000\n"
+
+func TestRoundtrip(t *testing.T) {
+	for _, version := range []int{2, 3} {
+		var cast bytes.Buffer
+		err := ScriptToAsciicast(strings.NewReader(testTypescript), strings.NewReader(testTimingfile), &cast, AsciicastOptions{Version: version})
+		if err != nil {
+			t.Fatalf("v%d: Error converting to asciicast: %v", version, err)
+		}
+
+		var typescript, timingfile bytes.Buffer
+		if err := AsciicastToScript(&cast, &typescript, &timingfile, ScriptOptions{}); err != nil {
+			t.Fatalf("v%d: Error converting to script: %v", version, err)
+		}
+
+		_, body, _ := strings.Cut(typescript.String(), "\n")
+		_, expectedBody, _ := strings.Cut(testTypescript, "\n")
+		if body != expectedBody {
+			t.Errorf("v%d: Wrong typescript:\nExpected: %q\nActual:   %q", version, expectedBody, body)
+		}
+
+		if timingfile.String() != testRoundtripTimingfile {
+			t.Errorf("v%d: Wrong timingfile:\nExpected: %q\nActual:   %q", version, testRoundtripTimingfile, timingfile.String())
+		}
+	}
+}
+
+func TestSkipInput(t *testing.T) {
+	var cast bytes.Buffer
+	err := ScriptToAsciicast(strings.NewReader(testTypescript), strings.NewReader(testTimingfile), &cast, AsciicastOptions{SkipInput: true})
+	if err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	if strings.Contains(cast.String(), `"i"`) {
+		t.Errorf("Input event not dropped:\n%s", cast.String())
+	}
+}
+
+func TestInfoMerged(t *testing.T) {
+	timingfile := "H 0.000000 COLUMNS 132\n" +
+		"H 0.000000 LINES 43\n" +
+		testTimingfile
+
+	var cast bytes.Buffer
+	err := ScriptToAsciicast(strings.NewReader(testTypescript), strings.NewReader(timingfile), &cast, AsciicastOptions{})
+	if err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	reader, err := asciicast.NewReader(&cast)
+	if err != nil {
+		t.Fatalf("Error reading asciicast: %v", err)
+	}
+
+	header := reader.Header()
+	if header.Width() != 132 || header.Height() != 43 {
+		t.Errorf("Wrong size (expected 132x43, got %dx%d)", header.Width(), header.Height())
+	}
+
+	if shell := header.Env()["SHELL"]; shell != "/bin/bash" {
+		t.Errorf("Wrong shell %q", shell)
+	}
+
+	if info := ScriptInfo(header); info[script.InfoTimingLog] != "timingfile" {
+		t.Errorf("Unmapped info entry lost: %v", info)
+	}
+}
+
+func TestMalformedInfo(t *testing.T) {
+	timingfile := "H 0.000000 START_TIME yesterday\n" +
+		"H 0.000000 COLUMNS wide\n" +
+		"H 0.000000 LINES 43\n" +
+		testTimingfile
+
+	var cast bytes.Buffer
+	err := ScriptToAsciicast(strings.NewReader(testTypescript), strings.NewReader(timingfile), &cast, AsciicastOptions{})
+	if err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	reader, err := asciicast.NewReader(&cast)
+	if err != nil {
+		t.Fatalf("Error reading asciicast: %v", err)
+	}
+
+	// The values of the typescript header are kept
+	header := reader.Header()
+	if header.Width() != 80 || header.Height() != 43 {
+		t.Errorf("Wrong size (expected 80x43, got %dx%d)", header.Width(), header.Height())
+	}
+	if timestamp, _ := header.Timestamp(); timestamp != 1743536096 {
+		t.Errorf("Wrong timestamp %d", timestamp)
+	}
+}
+
+func TestTrailer(t *testing.T) {
+	typescript := testTypescript + "\nScript done on 2025-04-01 12:35:00-07:00 [COMMAND_EXIT_CODE=\"3\"]\n"
+
+	for _, version := range []int{2, 3} {
+		var cast bytes.Buffer
+		opts := AsciicastOptions{Version: version, HoldHeader: version == 2}
+		err := ScriptToAsciicast(strings.NewReader(typescript), strings.NewReader(testTimingfile), &cast, opts)
+		if err != nil {
+			t.Fatalf("v%d: Error converting to asciicast: %v", version, err)
+		}
+
+		if version == 3 && !strings.Contains(cast.String(), `"x","3"]`) {
+			t.Errorf("v%d: Missing exit event:\n%s", version, cast.String())
+		}
+		if version == 2 && !strings.Contains(cast.String(), `"EXIT_CODE":"3"`) {
+			t.Errorf("v%d: Missing exit code metadata:\n%s", version, cast.String())
+		}
+
+		var typescriptOut, timingfileOut bytes.Buffer
+		if err := AsciicastToScript(&cast, &typescriptOut, &timingfileOut, ScriptOptions{}); err != nil {
+			t.Fatalf("v%d: Error converting to script: %v", version, err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(typescriptOut.String()), "\n")
+		trailer, err := script.ParseTrailer(lines[len(lines)-1])
+		if err != nil {
+			t.Fatalf("v%d: Error parsing trailer: %v\n%s", version, err, typescriptOut.String())
+		}
+		if trailer.ExitCode != 3 {
+			t.Errorf("v%d: Wrong exit code %d", version, trailer.ExitCode)
+		}
+		if !strings.Contains(timingfileOut.String(), "H 0.000000 EXIT_CODE 3\n") {
+			t.Errorf("v%d: Missing EXIT_CODE entry:\n%s", version, timingfileOut.String())
+		}
+	}
+}
+
+func TestExitDuration(t *testing.T) {
+	// The command exits a while after the last output
+	typescript := testTypescript + "\nScript done on 2025-04-01 12:35:01-07:00 [COMMAND_EXIT_CODE=\"0\"]\n"
+	timingfile := testTimingfile + "H 0.000000 DURATION 5.000000\n"
+
+	var cast bytes.Buffer
+	err := ScriptToAsciicast(strings.NewReader(typescript), strings.NewReader(timingfile), &cast, AsciicastOptions{Version: 3})
+	if err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	var typescriptOut, timingfileOut bytes.Buffer
+	if err := AsciicastToScript(&cast, &typescriptOut, &timingfileOut, ScriptOptions{}); err != nil {
+		t.Fatalf("Error converting to script: %v", err)
+	}
+
+	if !strings.Contains(timingfileOut.String(), "H 0.000000 DURATION 5.000000\n") {
+		t.Errorf("Duration not kept:\n%s", timingfileOut.String())
+	}
+	if !strings.Contains(typescriptOut.String(), "Script done on 2025-04-01 19:35:01+00:00") {
+		t.Errorf("Wrong trailer date:\n%s", typescriptOut.String())
+	}
+}
+
+func TestMalformedExitCode(t *testing.T) {
+	timingfile := testTimingfile + "H 0.000000 EXIT_CODE none\n"
+
+	for _, version := range []int{2, 3} {
+		var cast bytes.Buffer
+		opts := AsciicastOptions{Version: version, HoldHeader: version == 2}
+		err := ScriptToAsciicast(strings.NewReader(testTypescript), strings.NewReader(timingfile), &cast, opts)
+		if err != nil {
+			t.Fatalf("v%d: Error converting to asciicast: %v", version, err)
+		}
+
+		if strings.Contains(cast.String(), `"x",`) || strings.Contains(cast.String(), "EXIT_CODE") {
+			t.Errorf("v%d: Malformed exit code kept:\n%s", version, cast.String())
+		}
+	}
+
+	cast := `{"version":2,"width":80,"height":24,"timestamp":1743536096,"script_info":{"EXIT_CODE":"none"}}` + "\n" +
+		`[0.5,"o","hello"]` + "\n"
+
+	var typescriptOut, timingfileOut bytes.Buffer
+	if err := AsciicastToScript(strings.NewReader(cast), &typescriptOut, &timingfileOut, ScriptOptions{}); err != nil {
+		t.Fatalf("Error converting to script: %v", err)
+	}
+
+	if strings.Contains(typescriptOut.String(), "Script done") {
+		t.Errorf("Trailer written for a malformed exit code:\n%s", typescriptOut.String())
+	}
+	if strings.Contains(timingfileOut.String(), "EXIT_CODE") {
+		t.Errorf("Malformed EXIT_CODE entry kept:\n%s", timingfileOut.String())
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	// A timingfile cut off after the first events
+	readErr := errors.New("read error")
+	for _, version := range []int{2, 3} {
+		timingfile := io.MultiReader(strings.NewReader(testTimingfile[:strings.Index(testTimingfile, "S ")]), iotest.ErrReader(readErr))
+
+		var cast bytes.Buffer
+		err := ScriptToAsciicast(strings.NewReader(testTypescript), timingfile, &cast, AsciicastOptions{Version: version})
+		if err != readErr {
+			t.Fatalf("v%d: Expected read error, got %v", version, err)
+		}
+
+		if !strings.Contains(cast.String(), `"o","hello"]`) {
+			t.Errorf("v%d: Events read before the error not written:\n%s", version, cast.String())
+		}
+	}
+}
+
+func TestHeldHeaderWriteError(t *testing.T) {
+	readErr := errors.New("read error")
+	writeErr := errors.New("write error")
+	timingfile := io.MultiReader(strings.NewReader(testTimingfile[:strings.Index(testTimingfile, "S ")]), iotest.ErrReader(readErr))
+
+	err := ScriptToAsciicast(strings.NewReader(testTypescript), timingfile, errWriter{writeErr}, AsciicastOptions{HoldHeader: true})
+	if !errors.Is(err, readErr) || !errors.Is(err, writeErr) {
+		t.Errorf("Expected read and write errors, got %v", err)
+	}
+}
+
+// errWriter fails every write with err
+type errWriter struct{ err error }
+
+func (w errWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestMissingDimensions(t *testing.T) {
+	typescript := "Script started on Tue Apr  1 12:34:56 2025\nhello"
+	timingfile := "0.500000 5\n"
+
+	var cast bytes.Buffer
+	err := ScriptToAsciicast(strings.NewReader(typescript), strings.NewReader(timingfile), &cast, AsciicastOptions{Width: 132})
+	if err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	reader, err := asciicast.NewReader(&cast)
+	if err != nil {
+		t.Fatalf("Error reading asciicast: %v", err)
+	}
+
+	if w, h := reader.Header().Width(), reader.Header().Height(); w != 132 || h != 24 {
+		t.Errorf("Wrong size (expected 132x24, got %dx%d)", w, h)
+	}
+}
+
+func TestLocation(t *testing.T) {
+	typescript := "Script started on Tue Apr  1 12:34:56 2025\nhello"
+	timingfile := "0.500000 5\n"
+
+	var cast bytes.Buffer
+	opts := AsciicastOptions{Location: time.FixedZone("CEST", 2*60*60)}
+	if err := ScriptToAsciicast(strings.NewReader(typescript), strings.NewReader(timingfile), &cast, opts); err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	reader, err := asciicast.NewReader(&cast)
+	if err != nil {
+		t.Fatalf("Error reading asciicast: %v", err)
+	}
+
+	expected := time.Date(2025, 4, 1, 10, 34, 56, 0, time.UTC).Unix()
+	if timestamp, _ := reader.Header().Timestamp(); timestamp != expected {
+		t.Errorf("Wrong timestamp (expected %d, got %d)", expected, timestamp)
+	}
+}
+
+func TestSplitRunesRoundtrip(t *testing.T) {
+	typescript := `Script started on 2025-04-01 12:34:56-07:00 [TERM="xterm" TTY="/dev/pts/2" COLUMNS="80" LINES="24"]` + "\n" +
+		"こんにちは 🙂\r\n"
+	timingfile := "O 0.100000 4\n" +
+		"O 0.100000 7\n" +
+		"O 0.100000 6\n" +
+		"O 0.100000 5\n"
+
+	var cast bytes.Buffer
+	err := ScriptToAsciicast(strings.NewReader(typescript), strings.NewReader(timingfile), &cast, AsciicastOptions{})
+	if err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	if strings.ContainsRune(cast.String(), utf8.RuneError) {
+		t.Errorf("Characters corrupted:\n%s", cast.String())
+	}
+
+	var typescriptOut, timingOut bytes.Buffer
+	if err := AsciicastToScript(&cast, &typescriptOut, &timingOut, ScriptOptions{}); err != nil {
+		t.Fatalf("Error converting to script: %v", err)
+	}
+
+	_, body, _ := strings.Cut(typescriptOut.String(), "\n")
+	_, expectedBody, _ := strings.Cut(typescript, "\n")
+	if body != expectedBody {
+		t.Errorf("Wrong typescript:\nExpected: %q\nActual:   %q", expectedBody, body)
+	}
+}
+
+func TestEscapeBytesRoundtrip(t *testing.T) {
+	typescript := `Script started on 2025-04-01 12:34:56-07:00 [TERM="xterm" TTY="/dev/pts/2" COLUMNS="80" LINES="24"]` + "\n" +
+		"caf\xe9 \xff\xfe \U0010FFFF ok"
+	timingfile := "O 0.100000 5\n" +
+		"O 0.100000 4\n" +
+		"O 0.100000 6\n"
+
+	var cast bytes.Buffer
+	err := ScriptToAsciicast(strings.NewReader(typescript), strings.NewReader(timingfile), &cast, AsciicastOptions{EscapeBytes: true})
+	if err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	if strings.ContainsRune(cast.String(), utf8.RuneError) || !strings.Contains(cast.String(), `"escaped_bytes":true`) {
+		t.Errorf("Bytes not escaped:\n%s", cast.String())
+	}
+
+	var typescriptOut, timingOut bytes.Buffer
+	if err := AsciicastToScript(&cast, &typescriptOut, &timingOut, ScriptOptions{}); err != nil {
+		t.Fatalf("Error converting to script: %v", err)
+	}
+
+	_, body, _ := strings.Cut(typescriptOut.String(), "\n")
+	_, expectedBody, _ := strings.Cut(typescript, "\n")
+	if body != expectedBody {
+		t.Errorf("Wrong typescript:\nExpected: %q\nActual:   %q", expectedBody, body)
+	}
+}
+
+func TestReplacedBytes(t *testing.T) {
+	typescript := `Script started on 2025-04-01 12:34:56-07:00 [TERM="xterm" TTY="/dev/pts/2" COLUMNS="80" LINES="24"]` + "\n" +
+		"\xff ok \xfe"
+	timingfile := "O 0.100000 4\nO 0.100000 2\n"
+
+	for _, escape := range []bool{false, true} {
+		var calls int
+		opts := AsciicastOptions{EscapeBytes: escape, OnReplacedBytes: func() { calls++ }}
+		err := ScriptToAsciicast(strings.NewReader(typescript), strings.NewReader(timingfile), io.Discard, opts)
+		if err != nil {
+			t.Fatalf("Error converting to asciicast: %v", err)
+		}
+
+		expected := 1
+		if escape {
+			expected = 0
+		}
+		if calls != expected {
+			t.Errorf("EscapeBytes %v: OnReplacedBytes called %d times, expected %d", escape, calls, expected)
+		}
+	}
+}
+
+func TestCharset(t *testing.T) {
+	tests := []struct {
+		charset  string
+		data     string
+		expected string
+	}{
+		{"ISO-8859-1", "caf\xe9", "café"},
+		{"Shift_JIS", "\x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd", "こんにちは"},
+	}
+
+	for _, test := range tests {
+		// Split the data in the middle of a character
+		timingfile := fmt.Sprintf("O 0.100000 3\nO 0.100000 %d\n", len(test.data)-3)
+		typescript := `Script started on 2025-04-01 12:34:56-07:00 [TERM="xterm" TTY="/dev/pts/2" COLUMNS="80" LINES="24"]` + "\n" + test.data
+
+		var cast bytes.Buffer
+		err := ScriptToAsciicast(strings.NewReader(typescript), strings.NewReader(timingfile), &cast, AsciicastOptions{Charset: test.charset})
+		if err != nil {
+			t.Fatalf("%s: Error converting to asciicast: %v", test.charset, err)
+		}
+
+		reader, err := asciicast.NewReader(&cast)
+		if err != nil {
+			t.Fatalf("%s: Error reading asciicast: %v", test.charset, err)
+		}
+
+		var data strings.Builder
+		for {
+			event, err := reader.Next()
+			if err != nil {
+				break
+			}
+			data.WriteString(event.Data)
+		}
+
+		if data.String() != test.expected {
+			t.Errorf("%s: Expected %q, got %q", test.charset, test.expected, data.String())
+		}
+	}
+
+	err := ScriptToAsciicast(strings.NewReader(testTypescript), strings.NewReader(testTimingfile), io.Discard, AsciicastOptions{Charset: "no-such-charset"})
+	if err == nil {
+		t.Errorf("Expected error for unknown charset")
+	}
+
+	log := `{"ver":"2.3","host":"a","rec":"r1","user":"alice","term":"xterm","session":1,"id":1,"pos":0,"timing":"=80x24>5","in_txt":"","in_bin":[],"out_txt":"hello","out_bin":[]}` + "\n"
+	if err := TlogToAsciicast(strings.NewReader(log), io.Discard, AsciicastOptions{Charset: "ISO-8859-1"}); err == nil {
+		t.Errorf("Expected error for tlog with a charset")
+	}
+}
+
+func TestCharsetEntries(t *testing.T) {
+	// "é" at the end of an entry is a complete character in ISO-8859-1
+	typescript := `Script started on 2025-04-01 12:34:56-07:00 [TERM="xterm" TTY="/dev/pts/2" COLUMNS="80" LINES="24"]` + "\n" + "caf\xe9!"
+	timingfile := "O 0.100000 4\nO 0.200000 1\n"
+
+	var cast bytes.Buffer
+	err := ScriptToAsciicast(strings.NewReader(typescript), strings.NewReader(timingfile), &cast, AsciicastOptions{Charset: "ISO-8859-1"})
+	if err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	expectEvents(t, &cast, []asciicast.Event{
+		{Time: 0.1, Code: asciicast.OutputEvent, Data: "café"},
+		{Time: 0.3, Code: asciicast.OutputEvent, Data: "!"},
+	})
+}
+
+func TestLongSessionTiming(t *testing.T) {
+	// About 14 hours of events at nanosecond resolution, including very short delays
+	rng := rand.New(rand.NewPCG(1, 2))
+	var cast strings.Builder
+	cast.WriteString(`{"version": 2, "width": 80, "height": 24}` + "\n")
+	var times []int64 // nanoseconds
+	var now int64
+	for range 50000 {
+		delay := rng.Int64N(2_000_000_000)
+		if rng.IntN(4) == 0 {
+			delay = rng.Int64N(2000)
+		}
+		now += delay
+		times = append(times, now)
+		fmt.Fprintf(&cast, "[%d.%09d, \"o\", \"x\"]\n", now/1e9, now%1e9)
+	}
+
+	var typescript, timingfile bytes.Buffer
+	if err := AsciicastToScript(strings.NewReader(cast.String()), &typescript, &timingfile, ScriptOptions{}); err != nil {
+		t.Fatalf("Error converting to script: %v", err)
+	}
+
+	// Each event is within half a microsecond of its time in the asciicast
+	var outputTiming strings.Builder
+	var total int64 // microseconds
+	i := 0
+	for _, line := range strings.SplitAfter(timingfile.String(), "\n") {
+		if !strings.HasPrefix(line, "O ") {
+			continue
+		}
+		outputTiming.WriteString(line)
+
+		var seconds, micros int64
+		if _, err := fmt.Sscanf(line, "O %d.%06d 1\n", &seconds, &micros); err != nil {
+			t.Fatalf("Bad timing line %q: %v", line, err)
+		}
+		total += seconds*1e6 + micros
+
+		expected := (times[i] + 500) / 1000
+		if total != expected {
+			t.Fatalf("Event %d at %dus, expected %dus", i, total, expected)
+		}
+		i++
+	}
+	if i != len(times) {
+		t.Fatalf("Expected %d events, got %d", len(times), i)
+	}
+
+	// Converting back and forth changes nothing
+	for _, version := range []int{2, 3} {
+		var castOut bytes.Buffer
+		err := ScriptToAsciicast(bytes.NewReader(typescript.Bytes()), bytes.NewReader(timingfile.Bytes()), &castOut, AsciicastOptions{Version: version})
+		if err != nil {
+			t.Fatalf("v%d: Error converting to asciicast: %v", version, err)
+		}
+
+		var typescriptOut, timingOut bytes.Buffer
+		if err := AsciicastToScript(&castOut, &typescriptOut, &timingOut, ScriptOptions{}); err != nil {
+			t.Fatalf("v%d: Error converting to script: %v", version, err)
+		}
+
+		var timing strings.Builder
+		for _, line := range strings.SplitAfter(timingOut.String(), "\n") {
+			if strings.HasPrefix(line, "O ") {
+				timing.WriteString(line)
+			}
+		}
+		if timing.String() != outputTiming.String() {
+			t.Errorf("v%d: Timing changed by round trip", version)
+		}
+	}
+}
+
+func TestHeaderRoundtrip(t *testing.T) {
+	cast := `{"version": 2, "width": 100, "height": 30, "timestamp": 1743536096, "command": "htop -d 5", "title": "Demo",` +
+		` "idle_time_limit": 2, "env": {"TERM": "xterm", "SHELL": "/bin/zsh", "LANG": "C.UTF-8"},` +
+		` "theme": {"fg": "#ffffff", "bg": "#000000", "palette": "#000000:#ffffff"}, "x_custom": [1, 2]}` + "\n" +
+		`[0.5, "o", "hello"]` + "\n"
+
+	var typescript, timingfile bytes.Buffer
+	if err := AsciicastToScript(strings.NewReader(cast), &typescript, &timingfile, ScriptOptions{}); err != nil {
+		t.Fatalf("Error converting to script: %v", err)
+	}
+
+	if !strings.Contains(typescript.String(), `[COMMAND="htop -d 5" TERM="xterm"`) {
+		t.Errorf("Command not in the typescript header: %q", typescript.String())
+	}
+
+	for _, version := range []int{2, 3} {
+		var castOut bytes.Buffer
+		err := ScriptToAsciicast(bytes.NewReader(typescript.Bytes()), bytes.NewReader(timingfile.Bytes()), &castOut, AsciicastOptions{Version: version})
+		if err != nil {
+			t.Fatalf("v%d: Error converting to asciicast: %v", version, err)
+		}
+
+		reader, err := asciicast.NewReader(&castOut)
+		if err != nil {
+			t.Fatalf("v%d: Error reading asciicast: %v", version, err)
+		}
+		header := reader.Header()
+
+		if header.Width() != 100 || header.Height() != 30 {
+			t.Errorf("v%d: Wrong size %dx%d", version, header.Width(), header.Height())
+		}
+		if timestamp, _ := header.Timestamp(); timestamp != 1743536096 {
+			t.Errorf("v%d: Wrong timestamp %d", version, timestamp)
+		}
+		if command, _ := header.Command(); command != "htop -d 5" {
+			t.Errorf("v%d: Wrong command %q", version, command)
+		}
+		if title, _ := header.Title(); title != "Demo" {
+			t.Errorf("v%d: Wrong title %q", version, title)
+		}
+		if limit, _ := header.IdleTimeLimit(); limit != 2 {
+			t.Errorf("v%d: Wrong idle time limit %d", version, limit)
+		}
+
+		expectedEnv := map[string]string{"TERM": "xterm", "SHELL": "/bin/zsh", "LANG": "C.UTF-8"}
+		if !reflect.DeepEqual(header.Env(), expectedEnv) {
+			t.Errorf("v%d: Wrong env %v", version, header.Env())
+		}
+		if header.Theme()["fg"] != "#ffffff" {
+			t.Errorf("v%d: Wrong theme %v", version, header.Theme())
+		}
+		if string(header.Extra()["x_custom"]) != "[1,2]" {
+			t.Errorf("v%d: Unknown field not kept: %v", version, header.Extra())
+		}
+	}
+}
+
+func TestSparseHeaderRoundtrip(t *testing.T) {
+	// No timestamp or env, and the recording goes on after the last event
+	cast := `{"version": 2, "width": 80, "height": 24, "duration": 10.5}` + "\n" +
+		`[0.5, "o", "hello"]` + "\n" +
+		`[0.5, "x", "0"]` + "\n"
+
+	var typescript, timingfile bytes.Buffer
+	if err := AsciicastToScript(strings.NewReader(cast), &typescript, &timingfile, ScriptOptions{}); err != nil {
+		t.Fatalf("Error converting to script: %v", err)
+	}
+
+	if strings.Contains(typescript.String(), "Script done on") {
+		t.Errorf("Trailer without a start time:\n%s", typescript.String())
+	}
+	if !strings.Contains(timingfile.String(), "H 0.000000 DURATION 10.500000\n") {
+		t.Errorf("Wrong DURATION entry:\n%s", timingfile.String())
+	}
+	if strings.Contains(timingfile.String(), script.InfoStartTime) {
+		t.Errorf("START_TIME without a start time:\n%s", timingfile.String())
+	}
+
+	var castOut bytes.Buffer
+	err := ScriptToAsciicast(&typescript, &timingfile, &castOut, AsciicastOptions{})
+	if err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	headerLine, _, _ := strings.Cut(castOut.String(), "\n")
+	for _, field := range []string{`"timestamp":null`, `"duration":10.5`, `"env":null`} {
+		if !strings.Contains(headerLine, field) {
+			t.Errorf("Expected %s in header %s", field, headerLine)
+		}
+	}
+}
+
+func TestTtyRoundtrip(t *testing.T) {
+	var cast bytes.Buffer
+	if err := ScriptToAsciicast(strings.NewReader(testTypescript), strings.NewReader(testTimingfile), &cast, AsciicastOptions{}); err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	var typescript, timingfile bytes.Buffer
+	if err := AsciicastToScript(&cast, &typescript, &timingfile, ScriptOptions{}); err != nil {
+		t.Fatalf("Error converting to script: %v", err)
+	}
+
+	if !strings.Contains(typescript.String(), `TTY="/dev/pts/2"`) {
+		t.Errorf("TTY not kept: %q", typescript.String())
+	}
+}
+
+func TestMarkerRoundtrip(t *testing.T) {
+	cast := `{"version": 3, "term": {"cols": 80, "rows": 24}}` + "\n" +
+		`[0.5, "o", "hello"]` + "\n" +
+		`[0.25, "m", "Chapter 1"]` + "\n" +
+		`[0.25, "m", ""]` + "\n" +
+		`[0.5, "m", "\"quoted\"\nlabel"]` + "\n" +
+		`[0.5, "o", "world"]` + "\n"
+
+	var typescript, timingfile bytes.Buffer
+	if err := AsciicastToScript(strings.NewReader(cast), &typescript, &timingfile, ScriptOptions{}); err != nil {
+		t.Fatalf("Error converting to script: %v", err)
+	}
+
+	if !strings.Contains(timingfile.String(), "H 0.250000 MARKER Chapter 1\n") {
+		t.Errorf("Marker not written:\n%s", timingfile.String())
+	}
+
+	var castOut bytes.Buffer
+	if err := ScriptToAsciicast(&typescript, &timingfile, &castOut, AsciicastOptions{Version: 3}); err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	reader, err := asciicast.NewReader(&castOut)
+	if err != nil {
+		t.Fatalf("Error reading asciicast: %v", err)
+	}
+
+	if _, ok := reader.Header().Extra()[scriptInfoKey]; ok {
+		t.Errorf("Markers kept in the header: %s", reader.Header().Extra()[scriptInfoKey])
+	}
+
+	expected := []asciicast.Event{
+		{Time: 0.5, Code: asciicast.OutputEvent, Data: "hello"},
+		asciicast.NewMarkerEvent(0.75, "Chapter 1"),
+		asciicast.NewMarkerEvent(1, ""),
+		asciicast.NewMarkerEvent(1.5, "\"quoted\"\nlabel"),
+		{Time: 2, Code: asciicast.OutputEvent, Data: "world"},
+	}
+	for i, e := range expected {
+		event, err := reader.Next()
+		if err != nil {
+			t.Fatalf("Error reading event %d: %v", i, err)
+		}
+		if event != e {
+			t.Errorf("Event %d:\nExpected: %#v\nActual:   %#v", i, e, event)
+		}
+	}
+}
+
+// expectEvents reads an asciicast, checks that it holds the expected events,
+// and returns its header.
+func expectEvents(t *testing.T, cast io.Reader, expected []asciicast.Event) asciicast.Header {
+	t.Helper()
+
+	reader, err := asciicast.NewReader(cast)
+	if err != nil {
+		t.Fatalf("Error reading asciicast: %v", err)
+	}
+
+	for i, e := range expected {
+		event, err := reader.Next()
+		if err != nil {
+			t.Fatalf("Error reading event %d: %v", i, err)
+		}
+		if event != e {
+			t.Errorf("Event %d:\nExpected: %#v\nActual:   %#v", i, e, event)
+		}
+	}
+	if event, err := reader.Next(); err != io.EOF {
+		t.Errorf("Unexpected event after the expected ones: %#v, %v", event, err)
+	}
+
+	return reader.Header()
+}
@@ -0,0 +1,148 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"io"
+	"slices"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/vt"
+)
+
+// A screen to show during an animation
+type frame struct {
+	time  float64 // seconds from the start of the animation
+	lines []vt.Line
+
+	cursorX, cursorY int
+	cursorVisible    bool
+}
+
+// An asciicast replayed into frames
+type animation struct {
+	width, height int // largest screen size
+	palette       palette
+	frames        []frame
+	duration      float64 // including the final delay
+}
+
+// readAnimation replays an asciicast through a terminal emulator, taking a
+// frame whenever the screen changes, within the limits of opts.
+func readAnimation(cast io.Reader, opts AnimationOptions) (*animation, error) {
+	reader, err := asciicast.NewReader(cast)
+	if err != nil {
+		return nil, err
+	}
+
+	header := reader.Header()
+	anim := &animation{}
+	anim.palette = themePalette(header.Theme())
+
+	idleTimeLimit := opts.IdleTimeLimit
+	if limit, ok := header.IdleTimeLimit(); ok && idleTimeLimit == 0 {
+		idleTimeLimit = float64(limit)
+	}
+	var interval float64
+	if opts.MaxFPS > 0 {
+		interval = 1 / opts.MaxFPS
+	}
+
+	term := vt.NewForHeader(header)
+	unescape := byteUnescaper(header)
+
+	// Output is gathered into batches of one frame interval, which are shown at
+	// the time of their first event
+	var time, batchStart float64
+	var started, batch bool
+	previous := opts.Start
+	for {
+		event, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		if event.Code != asciicast.OutputEvent && event.Code != asciicast.ResizeEvent {
+			continue
+		}
+		if opts.End > 0 && event.Time > opts.End {
+			break
+		}
+
+		if event.Time >= opts.Start && !started {
+			// The first frame shows the output before the start
+			anim.snapshot(term, 0)
+			started = true
+		}
+
+		if started {
+			elapsed := max(event.Time-previous, 0)
+			if idleTimeLimit > 0 {
+				elapsed = min(elapsed, idleTimeLimit)
+			}
+			time += elapsed / opts.speed()
+			previous = max(event.Time, previous)
+		}
+
+		if batch && time > batchStart && time >= batchStart+interval {
+			anim.snapshot(term, batchStart)
+			batch = false
+		}
+
+		if event.Code == asciicast.OutputEvent {
+			event.Data = unescape(event.Data)
+		}
+		if err := term.Apply(event); err != nil {
+			return nil, err
+		}
+
+		if !batch && started {
+			batchStart = time
+			batch = true
+		}
+	}
+	if batch || !started {
+		anim.snapshot(term, batchStart)
+	}
+
+	anim.duration = anim.frames[len(anim.frames)-1].time + max(opts.FinalDelay, 0)
+	return anim, nil
+}
+
+// snapshot adds a frame of the terminal's screen, if it changed since the last frame.
+func (a *animation) snapshot(term *vt.Terminal, time float64) {
+	f := frame{time: time}
+	f.cursorX, f.cursorY = term.Cursor()
+	f.cursorVisible = term.CursorVisible()
+
+	var last *frame
+	if len(a.frames) != 0 {
+		last = &a.frames[len(a.frames)-1]
+	}
+
+	// Unchanged lines are shared with the last frame
+	changed := last == nil || len(last.lines) != len(term.Lines())
+	for y, line := range term.Lines() {
+		if last != nil && y < len(last.lines) && last.lines[y].Wrapped == line.Wrapped && slices.Equal(last.lines[y].Cells, line.Cells) {
+			f.lines = append(f.lines, last.lines[y])
+			continue
+		}
+		f.lines = append(f.lines, vt.Line{Cells: slices.Clone(line.Cells), Wrapped: line.Wrapped})
+		changed = true
+	}
+
+	width, height := term.Size()
+	a.width, a.height = max(a.width, width), max(a.height, height)
+
+	switch {
+	case last != nil && last.time == time:
+		*last = f
+	case changed || f.cursorX != last.cursorX || f.cursorY != last.cursorY || f.cursorVisible != last.cursorVisible:
+		a.frames = append(a.frames, f)
+	}
+}
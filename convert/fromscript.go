@@ -0,0 +1,204 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"errors"
+	"io"
+	"maps"
+	"strconv"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/internal/timing"
+	"github.com/wk-y/asciicast2script/script"
+)
+
+// ScriptToAsciicast converts a typescript and its timingfile into an asciicast written to cast.
+//
+// The asciicast is written as the recording is read, so its header only has
+// the info entries at the start of the timingfile. The exit status from the
+// typescript's trailer becomes an exit event in v3 asciicasts. v2 asciicasts
+// only keep it with AsciicastOptions.HoldHeader, as an EXIT_CODE entry of the
+// "script_info" header field.
+// "MARKER" info entries written by AsciicastToScript become marker events.
+func ScriptToAsciicast(typescript, timingfile io.Reader, cast io.Writer, opts AsciicastOptions) error {
+	return ScriptStreamsToAsciicast(typescript, nil, timingfile, cast, opts)
+}
+
+// ScriptStreamsToAsciicast is like ScriptToAsciicast for recordings with separate
+// output and input typescripts (script --log-out/--log-in).
+// If input is nil, input events are read from output. Output may be nil if only input was logged.
+func ScriptStreamsToAsciicast(output, input, timingfile io.Reader, cast io.Writer, opts AsciicastOptions) error {
+	encoder, err := newDataEncoder(opts)
+	if err != nil {
+		return err
+	}
+
+	// Characters split between entries are joined by the encoder
+	reader, err := script.NewMultiReaderOptions(output, input, timingfile, script.ReaderOptions{
+		Header: script.HeaderParser{Location: opts.Location, Dialects: opts.HeaderDialects},
+	})
+	if err != nil {
+		return err
+	}
+
+	version := opts.version()
+
+	// The exit status is only known at the end. v3 writes it as an exit event,
+	// and v2 keeps it in the header if the events are held until then.
+	var writer *asciicast.Writer
+	var events []asciicast.Event
+	writeHeader := func(info script.Info) error {
+		header := scriptHeader(reader.Header(), info, opts)
+		w, err := asciicast.NewWriter(cast, version, encoder.header(AsciicastHeader(header, info)))
+		if err != nil {
+			return err
+		}
+		writer = w
+		for _, acEvent := range events {
+			if err := writer.Write(acEvent); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	emit := func(acEvent asciicast.Event) error {
+		if writer == nil {
+			events = append(events, acEvent)
+			return nil
+		}
+		return writer.Write(acEvent)
+	}
+	// Events read before an error are still written
+	fail := func(err error) error {
+		if writer == nil {
+			info := maps.Clone(reader.Info())
+			delete(info, script.InfoExitCode)
+			if headerErr := writeHeader(info); headerErr != nil {
+				return errors.Join(err, headerErr)
+			}
+		}
+		return err
+	}
+
+	if version >= 3 || !opts.HoldHeader {
+		info := maps.Clone(reader.Info())
+		delete(info, script.InfoExitCode)
+		if err := writeHeader(info); err != nil {
+			return err
+		}
+	}
+
+	var clock timing.Clock
+	var time float64
+	for {
+		sEvent, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fail(err)
+		}
+
+		time = clock.Advance(sEvent.ElapsedSeconds)
+
+		acEvent := asciicast.Event{
+			Time: time,
+			Data: sEvent.Data,
+		}
+
+		var ignore bool
+		switch sEvent.Code {
+		case script.InputCode:
+			acEvent.Code = asciicast.InputEvent
+			ignore = opts.SkipInput
+		case script.OutputCode:
+			acEvent.Code = asciicast.OutputEvent
+		case script.SignalCode:
+			if sEvent.Name != script.SigWinch {
+				ignore = true
+				break
+			}
+
+			rows, cols, err := sEvent.WindowSize()
+			if err != nil {
+				return fail(err)
+			}
+			acEvent = asciicast.NewResizeEvent(time, cols, rows)
+		case script.InfoCode:
+			if sEvent.Name != markerInfoName {
+				ignore = true
+				break
+			}
+			acEvent = asciicast.NewMarkerEvent(time, markerLabel(sEvent.Data))
+		default:
+			ignore = true
+		}
+
+		if ignore {
+			continue
+		}
+
+		acEvent, ok, err := encoder.encode(acEvent)
+		if err != nil {
+			return fail(err)
+		}
+		if !ok {
+			continue
+		}
+		if err := emit(acEvent); err != nil {
+			return err
+		}
+	}
+
+	rest, err := encoder.flush(time)
+	if err != nil {
+		return fail(err)
+	}
+	for _, acEvent := range rest {
+		if err := emit(acEvent); err != nil {
+			return err
+		}
+	}
+
+	info := reader.Info()
+	if trailer, ok := reader.Trailer(); ok && trailer.Message == "" {
+		info[script.InfoExitCode] = strconv.Itoa(trailer.ExitCode)
+	}
+
+	// A malformed exit status is ignored, like other malformed info entries
+	status, err := strconv.Atoi(info[script.InfoExitCode])
+	exitKnown := err == nil
+	if !exitKnown {
+		delete(info, script.InfoExitCode)
+	}
+
+	if writer == nil {
+		return writeHeader(info)
+	}
+	if !exitKnown || version < 3 {
+		return nil
+	}
+
+	// The header was written before the duration was read, so the exit event keeps it
+	if duration, err := strconv.ParseFloat(info[script.InfoDuration], 64); err == nil {
+		time = max(time, duration)
+	}
+	return writer.Write(asciicast.NewExitEvent(time, status))
+}
+
+// scriptHeader returns a typescript's header with the info entries applied,
+// and the default size if the recording doesn't have one.
+func scriptHeader(header script.Header, info script.Info, opts AsciicastOptions) script.Header {
+	info.ApplyTo(&header)
+
+	if header.Columns == 0 {
+		header.Columns = opts.width()
+	}
+	if header.Lines == 0 {
+		header.Lines = opts.height()
+	}
+	return header
+}
@@ -0,0 +1,291 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"math"
+
+	"github.com/wk-y/asciicast2script/internal/font"
+	"github.com/wk-y/asciicast2script/vt"
+	"golang.org/x/text/unicode/norm"
+)
+
+// GIF frame delays are in hundredths of a second, and viewers slow down
+// shorter delays than 2
+const maxGIFFPS = 50
+
+// AsciicastToGIF renders an asciicast as an animated GIF.
+//
+// Text is drawn with a built-in bitmap font, in the colors of the asciicast's
+// theme or the asciinema player's default theme. Characters the font lacks
+// are drawn as boxes, except accented letters, which are drawn without accents.
+// Frames only hold the lines that changed, to keep the file small.
+func AsciicastToGIF(cast io.Reader, out io.Writer, opts GIFOptions) error {
+	if opts.MaxFPS <= 0 || opts.MaxFPS > maxGIFFPS {
+		opts.MaxFPS = maxGIFFPS
+	}
+	anim, err := readAnimation(cast, opts.AnimationOptions)
+	if err != nil {
+		return err
+	}
+
+	r := gifRenderer{
+		palette: anim.palette,
+		scale:   opts.scale(),
+	}
+	r.cellWidth, r.cellHeight = font.Width*r.scale, font.Height*r.scale
+	r.padding = r.cellWidth
+	bounds := image.Rect(0, 0, anim.width*r.cellWidth+2*r.padding, anim.height*r.cellHeight+2*r.padding)
+
+	g := &gif.GIF{
+		Config: image.Config{Width: bounds.Dx(), Height: bounds.Dy()},
+	}
+
+	centiseconds := func(t float64) int {
+		return int(math.Round(t * 100))
+	}
+
+	// Frames have palettes of their own if the animation has too many colors for one
+	colors := newGIFColors(anim)
+	for i, f := range anim.frames {
+		rect := bounds
+		first, last := 0, len(f.lines)-1
+		if i != 0 && len(f.lines) == len(anim.frames[i-1].lines) {
+			// Only draw the lines that changed, over the last frame
+			first, last = changedLines(anim.frames[i-1], f)
+			rect = image.Rect(0, r.padding+first*r.cellHeight, bounds.Dx(), r.padding+(last+1)*r.cellHeight)
+		}
+
+		r.colors = colors
+		if r.colors == nil {
+			r.colors = frameGIFColors(anim.palette, f.lines[first:last+1])
+		}
+		if i == 0 {
+			g.Config.ColorModel = r.colors.palette
+		}
+
+		img := image.NewPaletted(rect, r.colors.palette)
+		r.fill(img, rect, r.palette.bg)
+		r.drawLines(img, f, first, last, anim.width)
+
+		end := anim.duration
+		if i+1 < len(anim.frames) {
+			end = anim.frames[i+1].time
+		}
+		g.Image = append(g.Image, img)
+		g.Delay = append(g.Delay, max(centiseconds(end)-centiseconds(f.time), 2))
+		g.Disposal = append(g.Disposal, gif.DisposalNone)
+	}
+
+	return gif.EncodeAll(out, g)
+}
+
+// changedLines returns the range of lines that differ between two frames of the same size.
+func changedLines(previous, f frame) (first, last int) {
+	first, last = len(f.lines), -1
+	changed := func(y int) {
+		first, last = min(first, y), max(last, y)
+	}
+
+	for y, line := range f.lines {
+		old := previous.lines[y]
+		// Unchanged lines are shared between frames
+		if len(line.Cells) != len(old.Cells) || (len(line.Cells) != 0 && &line.Cells[0] != &old.Cells[0]) {
+			changed(y)
+		}
+	}
+	if previous.cursorVisible {
+		changed(previous.cursorY)
+	}
+	if f.cursorVisible {
+		changed(f.cursorY)
+	}
+
+	if last < 0 {
+		return 0, 0
+	}
+	return min(first, len(f.lines)-1), min(last, len(f.lines)-1)
+}
+
+// Colors of a GIF image, which can have at most 256
+type gifColors struct {
+	palette color.Palette
+	index   map[color.RGBA]uint8
+}
+
+// newGIFColors returns the colors used by all frames of an animation,
+// or nil if there are too many.
+func newGIFColors(anim *animation) *gifColors {
+	c := baseGIFColors(anim.palette)
+	seen := map[*vt.Cell]bool{}
+	for _, f := range anim.frames {
+		for _, line := range f.lines {
+			if len(line.Cells) == 0 || seen[&line.Cells[0]] {
+				continue
+			}
+			seen[&line.Cells[0]] = true
+			if !c.addLine(anim.palette, line) {
+				return nil
+			}
+		}
+	}
+	return c
+}
+
+// frameGIFColors returns the colors used by lines of a frame. If there are too
+// many, colors are mapped to the closest of the terminal's 256 colors.
+func frameGIFColors(pal palette, lines []vt.Line) *gifColors {
+	c := baseGIFColors(pal)
+	for _, line := range lines {
+		if !c.addLine(pal, line) {
+			c = &gifColors{index: map[color.RGBA]uint8{}}
+			for i := range 256 {
+				c.palette = append(c.palette, pal.color(vt.IndexedColor(uint8(i)), color.RGBA{}))
+			}
+			return c
+		}
+	}
+	return c
+}
+
+// baseGIFColors returns the colors drawn whatever the screen holds: the
+// background, and the default foreground used for the cursor.
+func baseGIFColors(pal palette) *gifColors {
+	c := &gifColors{index: map[color.RGBA]uint8{}}
+	c.add(pal.bg)
+	c.add(pal.fg)
+	return c
+}
+
+// add adds col to the palette, and reports whether it fit.
+func (c *gifColors) add(col color.RGBA) bool {
+	if _, ok := c.index[col]; ok {
+		return true
+	}
+	if len(c.palette) >= 256 {
+		return false
+	}
+	c.index[col] = uint8(len(c.palette))
+	c.palette = append(c.palette, col)
+	return true
+}
+
+// addLine adds the colors of the cells of line, and reports whether they fit.
+func (c *gifColors) addLine(pal palette, line vt.Line) bool {
+	for _, cell := range line.Cells {
+		fg, bg := pal.resolve(cell.Attr)
+		if !c.add(fg) || !c.add(bg) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexOf returns the palette index of col, or of the closest color.
+func (c *gifColors) indexOf(col color.RGBA) uint8 {
+	i, ok := c.index[col]
+	if !ok {
+		i = uint8(c.palette.Index(col))
+		c.index[col] = i
+	}
+	return i
+}
+
+type gifRenderer struct {
+	palette               palette
+	colors                *gifColors
+	scale                 int
+	cellWidth, cellHeight int
+	padding               int
+}
+
+func (r *gifRenderer) fill(img *image.Paletted, rect image.Rectangle, col color.RGBA) {
+	i := r.colors.indexOf(col)
+	rect = rect.Intersect(img.Rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(rect.Min.X, y):img.PixOffset(rect.Max.X, y)]
+		for x := range row {
+			row[x] = i
+		}
+	}
+}
+
+// drawLines draws lines first to last of a frame.
+func (r *gifRenderer) drawLines(img *image.Paletted, f frame, first, last, width int) {
+	for y := first; y <= last; y++ {
+		cells := f.lines[y].Cells
+		for x := 0; x < len(cells) && x < width; x++ {
+			if cells[x].Rune == 0 {
+				continue // drawn with the first half
+			}
+			columns := 1
+			if x+1 < len(cells) && cells[x+1].Rune == 0 {
+				columns = 2
+			}
+			cursor := f.cursorVisible && f.cursorX == x && f.cursorY == y
+			r.drawCell(img, r.padding+x*r.cellWidth, r.padding+y*r.cellHeight, cells[x], columns, cursor)
+		}
+	}
+}
+
+// drawCell draws a cell spanning the given number of columns at x, y.
+func (r *gifRenderer) drawCell(img *image.Paletted, x, y int, cell vt.Cell, columns int, cursor bool) {
+	fg, bg := r.palette.resolve(cell.Attr)
+	if cursor {
+		fg, bg = bg, r.palette.fg
+	}
+	r.fill(img, image.Rect(x, y, x+columns*r.cellWidth, y+r.cellHeight), bg)
+	if cell.Attr.Flags&vt.Hidden != 0 {
+		return
+	}
+
+	glyph := glyphOf(cell.Rune)
+	if cell.Attr.Flags&vt.Bold != 0 {
+		// Bold by drawing the glyph again one pixel to the right
+		for i, row := range glyph {
+			glyph[i] = row | row>>1
+		}
+	}
+	if cell.Attr.Flags&vt.Underline != 0 {
+		glyph[font.UnderlineRow] = 1<<font.Width - 1
+	}
+	if cell.Attr.Flags&vt.Strikethrough != 0 {
+		glyph[font.StrikethroughRow] = 1<<font.Width - 1
+	}
+
+	// Wide characters are centered in their cells
+	x += (columns - 1) * r.cellWidth / 2
+	for gy := range font.Height {
+		for gx := range font.Width {
+			if glyph.Set(gx, gy) {
+				r.fill(img, image.Rect(x+gx*r.scale, y+gy*r.scale, x+(gx+1)*r.scale, y+(gy+1)*r.scale), fg)
+			}
+		}
+	}
+	if columns == 2 && cell.Attr.Flags&vt.Underline != 0 {
+		r.fill(img, image.Rect(x-r.cellWidth/2, y+font.UnderlineRow*r.scale, x+r.cellWidth*3/2, y+(font.UnderlineRow+1)*r.scale), fg)
+	}
+}
+
+// glyphOf returns the bitmap drawn for r. Letters with accents are drawn
+// without them, and other characters the font lacks as a box.
+func glyphOf(r rune) font.Bitmap {
+	if r == ' ' {
+		return font.Bitmap{}
+	}
+	if glyph, ok := font.Glyph(r); ok {
+		return glyph
+	}
+	if base := []rune(norm.NFD.String(string(r))); len(base) > 1 {
+		if glyph, ok := font.Glyph(base[0]); ok {
+			return glyph
+		}
+	}
+	return font.Missing
+}
@@ -0,0 +1,111 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"image/gif"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wk-y/asciicast2script/internal/font"
+)
+
+func TestAsciicastToGIF(t *testing.T) {
+	cast := `{"version": 2, "width": 4, "height": 2, "theme": {"fg": "#ffffff", "bg": "#102030"}}` + "\n" +
+		`[1, "o", "a"]` + "\n" +
+		`[2, "o", "b"]` + "\n" +
+		`[3, "o", "\u001b[31mc"]` + "\n" +
+		`[4, "o", "d"]` + "\n"
+
+	opts := GIFOptions{
+		AnimationOptions: AnimationOptions{FinalDelay: 1, Speed: 2, Start: 1.5, End: 3.5},
+		Scale:            1,
+	}
+	var buf bytes.Buffer
+	if err := AsciicastToGIF(strings.NewReader(cast), &buf, opts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("Invalid GIF: %v", err)
+	}
+
+	if g.Config.Width != 6*font.Width || g.Config.Height != 2*font.Height+2*font.Width {
+		t.Errorf("Wrong size %dx%d", g.Config.Width, g.Config.Height)
+	}
+	if expected := []int{25, 50, 100}; !reflect.DeepEqual(g.Delay, expected) {
+		t.Errorf("Wrong delays:\nExpected: %v\nActual:   %v", expected, g.Delay)
+	}
+	if len(g.Image) != 3 {
+		t.Fatalf("Expected 3 frames, got %d", len(g.Image))
+	}
+	if g.Image[1].Bounds().Dy() != font.Height {
+		t.Errorf("Frame not limited to the changed line: %v", g.Image[1].Bounds())
+	}
+
+	first := g.Image[0]
+	if c := color.RGBAModel.Convert(first.At(0, 0)); c != (color.RGBA{0x10, 0x20, 0x30, 0xff}) {
+		t.Errorf("Wrong background %v", c)
+	}
+
+	// Find the red c in the last frame
+	last := g.Image[len(g.Image)-1]
+	var red bool
+	for y := last.Bounds().Min.Y; y < last.Bounds().Max.Y; y++ {
+		for x := last.Bounds().Min.X; x < last.Bounds().Max.X; x++ {
+			if color.RGBAModel.Convert(last.At(x, y)) == defaultPalette.ansi[1] {
+				red = true
+			}
+		}
+	}
+	if !red {
+		t.Errorf("Red text not drawn")
+	}
+}
+
+func TestGIFColors(t *testing.T) {
+	// 200 colors in each frame, 400 in all
+	cellColor := func(frame, i int) color.RGBA {
+		return color.RGBA{uint8(frame), uint8(i), 0x80, 0xff}
+	}
+	cast := `{"version": 2, "width": 20, "height": 10}` + "\n"
+	for frame := range 2 {
+		var output strings.Builder
+		output.WriteString(`\u001b[H`)
+		for i := range 200 {
+			c := cellColor(frame, i)
+			fmt.Fprintf(&output, `\u001b[48;2;%d;%d;%dm `, c.R, c.G, c.B)
+		}
+		output.WriteString(`\u001b[0m\u001b[?25l`)
+		cast += fmt.Sprintf(`[%d, "o", "%s"]`, frame+1, output.String()) + "\n"
+	}
+
+	var buf bytes.Buffer
+	if err := AsciicastToGIF(strings.NewReader(cast), &buf, GIFOptions{Scale: 1}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("Invalid GIF: %v", err)
+	}
+	if len(g.Image) != 3 {
+		t.Fatalf("Expected 3 frames, got %d", len(g.Image))
+	}
+
+	// Every cell is drawn in its exact color
+	for frame, img := range g.Image[1:] {
+		for i := range 200 {
+			x, y := font.Width*(1+i%20), font.Width+font.Height*(i/20)
+			if c := color.RGBAModel.Convert(img.At(x, y)); c != cellColor(frame, i) {
+				t.Fatalf("Frame %d, cell %d: expected %v, got %v", frame+1, i, cellColor(frame, i), c)
+			}
+		}
+	}
+}
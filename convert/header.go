@@ -0,0 +1,203 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/script"
+)
+
+// Asciicast header field holding script info entries that have no asciicast equivalent
+const scriptInfoKey = "script_info"
+
+// Script info entry holding the asciicast header fields that have no script
+// equivalent, as a JSON object in the format of a v2 header
+const asciicastInfoName = "ASCIICAST"
+
+// Asciicast header fields describing the event data rather than the session,
+// which aren't carried over to script
+var dataKeys = []string{escapedBytesKey}
+
+// Info entries that map to asciicast header fields
+var mappedInfo = []string{
+	script.InfoStartTime,
+	script.InfoTerm,
+	script.InfoColumns,
+	script.InfoLines,
+	script.InfoCommand,
+	script.InfoShell,
+	script.InfoDuration,
+	asciicastInfoName,
+	markerInfoName, // markers become marker events
+}
+
+// AsciicastHeader converts a script header and info entries to an asciicast header.
+// Info entries and header fields without an asciicast equivalent, such as the TTY,
+// are kept in the "script_info" field. Asciicast fields kept in an "ASCIICAST"
+// info entry by ScriptInfo are restored.
+func AsciicastHeader(header script.Header, info script.Info) asciicast.Header {
+	var acHeader asciicast.HeaderV2
+	if raw, ok := info[asciicastInfoName]; ok {
+		// Fields set below take precedence
+		var unmapped asciicast.HeaderV2
+		if err := json.Unmarshal([]byte(raw), &unmapped); err == nil {
+			acHeader = unmapped
+		}
+	}
+
+	acHeader.Version = 2
+	if acHeader.Env == nil {
+		acHeader.Env = map[string]string{}
+	}
+
+	acHeader.Width = header.Columns
+	acHeader.Height = header.Lines
+	if !header.Start.IsZero() {
+		timestamp := header.Start.Unix()
+		acHeader.Timestamp = &timestamp
+	}
+
+	if header.Term != "" {
+		acHeader.Env["TERM"] = header.Term
+	}
+	if shell, ok := info[script.InfoShell]; ok {
+		acHeader.Env["SHELL"] = shell
+	}
+	if len(acHeader.Env) == 0 {
+		acHeader.Env = nil
+	}
+
+	if header.Command != "" {
+		acHeader.Command = &header.Command
+	}
+
+	if duration, err := strconv.ParseFloat(info[script.InfoDuration], 64); err == nil {
+		acHeader.Duration = &duration
+	}
+
+	unmapped := map[string]string{}
+	for name, value := range script.HeaderInfo(header) {
+		unmapped[name] = value
+	}
+	for name, value := range info {
+		unmapped[name] = value
+	}
+	for _, name := range mappedInfo {
+		delete(unmapped, name)
+	}
+	if len(unmapped) != 0 {
+		if encoded, err := json.Marshal(unmapped); err == nil {
+			if acHeader.Extra == nil {
+				acHeader.Extra = map[string]json.RawMessage{}
+			}
+			acHeader.Extra[scriptInfoKey] = encoded
+		}
+	}
+
+	return asciicast.HeaderV2Iface{Header: acHeader}
+}
+
+// Script info entries kept in the "script_info" asciicast header field
+func scriptInfoExtra(header asciicast.Header) script.Info {
+	var unmapped script.Info
+	if raw, ok := header.Extra()[scriptInfoKey]; ok {
+		json.Unmarshal(raw, &unmapped)
+	}
+	return unmapped
+}
+
+// ScriptHeader converts an asciicast header to a script header.
+// Asciicasts don't record a time zone, so the start time is in UTC.
+func ScriptHeader(header asciicast.Header) script.Header {
+	var result script.Header
+	if timestamp, ok := header.Timestamp(); ok {
+		result.Start = time.Unix(timestamp, 0).UTC()
+	}
+
+	if term, ok := header.Term(); ok {
+		result.Term = term
+	}
+
+	if command, ok := header.Command(); ok {
+		result.Command = command
+	}
+
+	result.Tty = scriptInfoExtra(header)[script.InfoTty]
+	result.Columns = header.Width()
+	result.Lines = header.Height()
+	return result
+}
+
+// ScriptInfo converts an asciicast header to script info entries.
+//
+// Header fields without a script equivalent, such as the title, environment
+// variables other than TERM and SHELL, and unknown fields, are kept in an
+// "ASCIICAST" entry holding them as a JSON object. The duration is kept there
+// too, since the DURATION entry is only written at the end of the recording.
+func ScriptInfo(header asciicast.Header) script.Info {
+	info := script.HeaderInfo(ScriptHeader(header))
+
+	if shell, ok := header.Env()["SHELL"]; ok {
+		info[script.InfoShell] = shell
+	}
+
+	if duration, ok := header.Duration(); ok {
+		info[script.InfoDuration] = fmt.Sprintf("%f", duration)
+	}
+
+	for name, value := range scriptInfoExtra(header) {
+		if _, ok := info[name]; !ok {
+			info[name] = value
+		}
+	}
+
+	if unmapped := unmappedFields(header); len(unmapped) != 0 {
+		if encoded, err := json.Marshal(unmapped); err == nil {
+			info[asciicastInfoName] = string(encoded)
+		}
+	}
+
+	return info
+}
+
+// Asciicast header fields that have no script equivalent
+func unmappedFields(header asciicast.Header) map[string]any {
+	unmapped := map[string]any{}
+
+	if title, ok := header.Title(); ok {
+		unmapped["title"] = title
+	}
+	if duration, ok := header.Duration(); ok {
+		unmapped["duration"] = duration
+	}
+	if idleTimeLimit, ok := header.IdleTimeLimit(); ok {
+		unmapped["idle_time_limit"] = idleTimeLimit
+	}
+	if theme := header.Theme(); len(theme) != 0 {
+		unmapped["theme"] = theme
+	}
+
+	env := maps.Clone(header.Env())
+	delete(env, "TERM")
+	delete(env, "SHELL")
+	if len(env) != 0 {
+		unmapped["env"] = env
+	}
+
+	for key, value := range header.Extra() {
+		if key != scriptInfoKey && !slices.Contains(dataKeys, key) {
+			unmapped[key] = value
+		}
+	}
+
+	return unmapped
+}
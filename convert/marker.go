@@ -0,0 +1,38 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/wk-y/asciicast2script/script"
+)
+
+// Script info entry holding an asciicast marker.
+// scriptreplay ignores info entries it doesn't know, so markers can be kept in the timingfile.
+const markerInfoName = "MARKER"
+
+// scriptMarker returns the info event of a marker.
+//
+// The label is the rest of the entry's line. Labels which can't be written
+// that way, because they contain line breaks or start with a quote,
+// are written as a JSON string.
+func scriptMarker(elapsed float64, label string) script.Event {
+	if strings.ContainsAny(label, "\r\n") || strings.HasPrefix(label, `"`) {
+		encoded, _ := json.Marshal(label)
+		label = string(encoded)
+	}
+	return script.Event{ElapsedSeconds: elapsed, Code: script.InfoCode, Name: markerInfoName, Data: label}
+}
+
+// markerLabel returns the label of a marker info entry.
+func markerLabel(data string) string {
+	var label string
+	if strings.HasPrefix(data, `"`) && json.Unmarshal([]byte(data), &label) == nil {
+		return label
+	}
+	return data
+}
@@ -0,0 +1,149 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/wk-y/asciicast2script/vt"
+)
+
+// Colors for rendering a terminal
+type palette struct {
+	fg, bg color.RGBA
+	ansi   [16]color.RGBA
+}
+
+// The asciinema player's default theme
+var defaultPalette = palette{
+	fg: color.RGBA{0xcc, 0xcc, 0xcc, 0xff},
+	bg: color.RGBA{0x12, 0x13, 0x14, 0xff},
+	ansi: [16]color.RGBA{
+		{0x00, 0x00, 0x00, 0xff},
+		{0xdd, 0x3c, 0x69, 0xff},
+		{0x4e, 0xbf, 0x22, 0xff},
+		{0xdd, 0xaf, 0x3c, 0xff},
+		{0x26, 0xb0, 0xd7, 0xff},
+		{0xb9, 0x54, 0xe1, 0xff},
+		{0x54, 0xe1, 0xb9, 0xff},
+		{0xd9, 0xd9, 0xd9, 0xff},
+		{0x4d, 0x4d, 0x4d, 0xff},
+		{0xdd, 0x3c, 0x69, 0xff},
+		{0x4e, 0xbf, 0x22, 0xff},
+		{0xdd, 0xaf, 0x3c, 0xff},
+		{0x26, 0xb0, 0xd7, 0xff},
+		{0xb9, 0x54, 0xe1, 0xff},
+		{0x54, 0xe1, 0xb9, 0xff},
+		{0xff, 0xff, 0xff, 0xff},
+	},
+}
+
+// themePalette returns the palette of an asciicast header's theme, which has
+// "fg" and "bg" colors and a "palette" of 8 or 16 colors separated by ':'.
+// Colors missing from the theme or invalid are taken from the default palette.
+func themePalette(theme map[string]string) palette {
+	p := defaultPalette
+
+	for _, field := range []struct {
+		key   string
+		color *color.RGBA
+	}{{"fg", &p.fg}, {"bg", &p.bg}} {
+		if c, err := parseHexColor(theme[field.key]); err == nil {
+			*field.color = c
+		}
+	}
+
+	if ansi, ok := parseThemePalette(theme["palette"]); ok {
+		p.ansi = ansi
+	}
+
+	return p
+}
+
+// parseThemePalette parses 8 or 16 colors separated by ':'.
+// With 8 colors, the bright colors are the same.
+func parseThemePalette(s string) (ansi [16]color.RGBA, ok bool) {
+	colors := strings.Split(s, ":")
+	if len(colors) != 8 && len(colors) != 16 {
+		return ansi, false
+	}
+	for i, value := range colors {
+		c, err := parseHexColor(value)
+		if err != nil {
+			return ansi, false
+		}
+		ansi[i] = c
+		if len(colors) == 8 {
+			ansi[i+8] = c
+		}
+	}
+	return ansi, true
+}
+
+// parseHexColor parses a CSS color of the form #rrggbb or #rgb.
+func parseHexColor(s string) (color.RGBA, error) {
+	hex, ok := strings.CutPrefix(s, "#")
+	if ok && len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if !ok || len(hex) != 6 || err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.RGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 0xff}, nil
+}
+
+// color returns the RGB value of c, or def for the default color.
+func (p palette) color(c vt.Color, def color.RGBA) color.RGBA {
+	if r, g, b, ok := c.RGB(); ok {
+		return color.RGBA{r, g, b, 0xff}
+	}
+	i, ok := c.Index()
+	switch {
+	case !ok:
+		return def
+	case i < 16:
+		return p.ansi[i]
+	case i < 232:
+		// 6x6x6 color cube
+		levels := [6]uint8{0, 95, 135, 175, 215, 255}
+		i -= 16
+		return color.RGBA{levels[i/36], levels[i/6%6], levels[i%6], 0xff}
+	default:
+		// Grayscale ramp
+		level := 8 + 10*(i-232)
+		return color.RGBA{level, level, level, 0xff}
+	}
+}
+
+// resolve returns the foreground and background colors of a cell with the given attributes.
+// Bold text in one of the first 8 colors is shown in the bright variant.
+func (p palette) resolve(attr vt.Attr) (fg, bg color.RGBA) {
+	fgColor := attr.FG
+	if i, ok := fgColor.Index(); ok && i < 8 && attr.Flags&vt.Bold != 0 {
+		fgColor = vt.IndexedColor(i + 8)
+	}
+
+	fg = p.color(fgColor, p.fg)
+	bg = p.color(attr.BG, p.bg)
+	if attr.Flags&vt.Inverse != 0 {
+		fg, bg = bg, fg
+	}
+	if attr.Flags&vt.Faint != 0 {
+		fg = color.RGBA{
+			uint8((uint16(fg.R) + uint16(bg.R)) / 2),
+			uint8((uint16(fg.G) + uint16(bg.G)) / 2),
+			uint8((uint16(fg.B) + uint16(bg.B)) / 2),
+			0xff,
+		}
+	}
+	if attr.Flags&vt.Hidden != 0 {
+		fg = bg
+	}
+	return fg, bg
+}
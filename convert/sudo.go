@@ -0,0 +1,183 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"cmp"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/internal/timing"
+	"github.com/wk-y/asciicast2script/sudoio"
+)
+
+// SudoToAsciicast converts the sudo I/O log in fsys into an asciicast.
+// Use os.DirFS to convert an I/O log directory.
+//
+// Terminal output and the command's stdout and stderr become output events,
+// terminal input and stdin become input events.
+// If the log doesn't record the terminal size, the size from opts is used.
+func SudoToAsciicast(fsys fs.FS, cast io.Writer, opts AsciicastOptions) error {
+	reader, err := sudoio.Open(fsys)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	encoder, err := newDataEncoder(opts)
+	if err != nil {
+		return err
+	}
+
+	log := reader.Log()
+	timestamp := log.Start.Unix()
+	header := asciicast.HeaderV2{
+		Version:   2,
+		Width:     cmp.Or(log.Columns, opts.width()),
+		Height:    cmp.Or(log.Lines, opts.height()),
+		Timestamp: &timestamp,
+	}
+	if log.Command != "" {
+		header.Command = &log.Command
+	}
+
+	writer, err := asciicast.NewWriter(cast, opts.version(), encoder.header(asciicast.HeaderV2Iface{Header: header}))
+	if err != nil {
+		return err
+	}
+
+	var clock timing.Clock
+	var time float64
+	for {
+		sEvent, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		time = clock.Advance(sEvent.ElapsedSeconds)
+
+		var acEvent asciicast.Event
+		switch {
+		case sEvent.Stream == sudoio.WindowSize:
+			acEvent = asciicast.NewResizeEvent(time, sEvent.Cols, sEvent.Rows)
+		case sEvent.Stream.IsInput():
+			if opts.SkipInput {
+				continue
+			}
+			acEvent = asciicast.Event{Time: time, Code: asciicast.InputEvent, Data: sEvent.Data}
+		case sEvent.Stream.HasData():
+			acEvent = asciicast.Event{Time: time, Code: asciicast.OutputEvent, Data: sEvent.Data}
+		default:
+			continue
+		}
+
+		acEvent, ok, err := encoder.encode(acEvent)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := writer.Write(acEvent); err != nil {
+			return err
+		}
+	}
+
+	rest, err := encoder.flush(time)
+	if err != nil {
+		return err
+	}
+	for _, acEvent := range rest {
+		if err := writer.Write(acEvent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AsciicastToSudo converts an asciicast into a sudo I/O log in the directory dir,
+// which can be played back with sudoreplay.
+//
+// Output events are written to ttyout, input events to ttyin.
+// The asciicast doesn't record who ran the command, so the log names
+// the user from the USER variable of the header's env, or "unknown", running as root.
+func AsciicastToSudo(cast io.Reader, dir string, opts SudoOptions) error {
+	reader, err := asciicast.NewReader(cast)
+	if err != nil {
+		return err
+	}
+
+	header := reader.Header()
+	log := sudoio.Log{
+		Start:     time.Unix(0, 0),
+		User:      cmp.Or(header.Env()["USER"], "unknown"),
+		RunasUser: "root",
+		Tty:       "/dev/tty",
+		Lines:     header.Height(),
+		Columns:   header.Width(),
+		Cwd:       "/",
+		Command:   cmp.Or(header.Env()["SHELL"], "/bin/sh"),
+	}
+	if timestamp, ok := header.Timestamp(); ok {
+		log.Start = time.Unix(timestamp, 0).UTC()
+	}
+	if command, ok := header.Command(); ok {
+		log.Command = command
+	}
+
+	unescape := byteUnescaper(header)
+
+	writer, err := sudoio.Create(dir, log)
+	if err != nil {
+		return err
+	}
+
+	var delta timing.Delta
+	for {
+		acEvent, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			writer.Close()
+			return err
+		}
+
+		event := sudoio.Event{Data: unescape(acEvent.Data)}
+
+		switch acEvent.Code {
+		case asciicast.OutputEvent:
+			event.Stream = sudoio.TtyOut
+		case asciicast.InputEvent:
+			if opts.SkipInput {
+				continue
+			}
+			event.Stream = sudoio.TtyIn
+		case asciicast.ResizeEvent:
+			cols, rows, err := acEvent.Resize()
+			if err != nil {
+				writer.Close()
+				return err
+			}
+			event = sudoio.Event{Stream: sudoio.WindowSize, Rows: rows, Cols: cols}
+		default:
+			continue
+		}
+
+		event.ElapsedSeconds = delta.Elapsed(acEvent.Time)
+		if err := writer.Write(event); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+
+	return writer.Close()
+}
@@ -0,0 +1,45 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+)
+
+func TestSudoRoundtrip(t *testing.T) {
+	cast := `{"version": 2, "width": 80, "height": 24, "timestamp": 1743536096, "command": "/usr/bin/vi", "env": {"USER": "alice"}}` + "\n" +
+		`[0.5, "o", "hello"]` + "\n" +
+		`[0.75, "i", ":q"]` + "\n" +
+		`[1.0, "r", "100x30"]` + "\n" +
+		`[1.5, "o", "world"]` + "\n"
+
+	dir := t.TempDir()
+	if err := AsciicastToSudo(strings.NewReader(cast), dir, SudoOptions{}); err != nil {
+		t.Fatalf("Error converting to sudo I/O log: %v", err)
+	}
+
+	var castOut bytes.Buffer
+	if err := SudoToAsciicast(os.DirFS(dir), &castOut, AsciicastOptions{}); err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	header := expectEvents(t, &castOut, []asciicast.Event{
+		{Time: 0.5, Code: asciicast.OutputEvent, Data: "hello"},
+		{Time: 0.75, Code: asciicast.InputEvent, Data: ":q"},
+		asciicast.NewResizeEvent(1, 100, 30),
+		{Time: 1.5, Code: asciicast.OutputEvent, Data: "world"},
+	})
+	if timestamp, _ := header.Timestamp(); timestamp != 1743536096 {
+		t.Errorf("Wrong timestamp %d", timestamp)
+	}
+	if command, _ := header.Command(); command != "/usr/bin/vi" {
+		t.Errorf("Wrong command %q", command)
+	}
+}
@@ -0,0 +1,258 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/wk-y/asciicast2script/vt"
+)
+
+// Layout of SVG output, in pixels
+const (
+	svgFontSize   = 14
+	svgCellWidth  = 8.4 // 0.6em, the advance of common monospace fonts
+	svgLineHeight = 17
+	svgBaseline   = 13 // from the top of a line
+	svgPadding    = 10
+)
+
+// Local fonts only, so the SVG works offline
+const svgFontFamily = `ui-monospace, SFMono-Regular, Menlo, Consolas, "DejaVu Sans Mono", "Liberation Mono", monospace`
+
+// AsciicastToSVG renders an asciicast as an animated SVG.
+//
+// The SVG is self-contained: the animation is done with CSS, without scripts,
+// and text uses locally installed monospace fonts. Colors come from the
+// asciicast's theme, or the asciinema player's default theme. Each distinct
+// line is drawn once and reused by the frames showing it.
+func AsciicastToSVG(cast io.Reader, svg io.Writer, opts AnimationOptions) error {
+	anim, err := readAnimation(cast, opts)
+	if err != nil {
+		return err
+	}
+
+	screenWidth := float64(anim.width) * svgCellWidth
+	screenHeight := float64(anim.height * svgLineHeight)
+	pal := anim.palette
+
+	lines := svgLines{palette: pal, ids: map[string]string{}, cache: map[*vt.Cell]string{}}
+	var frames strings.Builder
+	for i, f := range anim.frames {
+		fmt.Fprintf(&frames, `<g transform="translate(0,%s)">`, svgNumber(float64(i)*screenHeight))
+		for y, line := range f.lines {
+			if id := lines.id(line); id != "" {
+				fmt.Fprintf(&frames, `<use xlink:href="#%s" y="%d"/>`, id, y*svgLineHeight)
+			}
+		}
+		if f.cursorVisible && f.cursorY < len(f.lines) && f.cursorX < len(f.lines[f.cursorY].Cells) {
+			frames.WriteString(svgCursor(f.lines[f.cursorY], f.cursorX, f.cursorY, pal))
+		}
+		frames.WriteString("</g>\n")
+	}
+
+	w := bufio.NewWriter(svg)
+	width, height := screenWidth+2*svgPadding, screenHeight+2*svgPadding
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%s" height="%s" viewBox="0 0 %s %s">`+"\n",
+		svgNumber(width), svgNumber(height), svgNumber(width), svgNumber(height))
+
+	fmt.Fprintf(w, "<style>\ntext { font-family: %s; font-size: %dpx; white-space: pre; fill: %s; }\n", svgFontFamily, svgFontSize, hexColor(pal.fg))
+	if len(anim.frames) > 1 && anim.duration > 0 {
+		fmt.Fprintf(w, "#frames { animation: play %sms steps(1, end) infinite; }\n", svgNumber(math.Round(anim.duration*1000)))
+		w.WriteString("@keyframes play {\n")
+		for i, f := range anim.frames {
+			fmt.Fprintf(w, "%.3f%% { transform: translateY(%spx); }\n", f.time/anim.duration*100, svgNumber(float64(-i)*screenHeight))
+		}
+		fmt.Fprintf(w, "100%% { transform: translateY(%spx); }\n}\n", svgNumber(float64(1-len(anim.frames))*screenHeight))
+	}
+	w.WriteString("</style>\n")
+
+	fmt.Fprintf(w, `<rect width="100%%" height="100%%" rx="4" fill="%s"/>`+"\n", hexColor(pal.bg))
+	fmt.Fprintf(w, `<svg x="%d" y="%d" width="%s" height="%s" xml:space="preserve">`+"\n",
+		svgPadding, svgPadding, svgNumber(screenWidth), svgNumber(screenHeight))
+	w.WriteString("<defs>\n")
+	w.WriteString(lines.defs.String())
+	w.WriteString("</defs>\n")
+	w.WriteString(`<g id="frames">` + "\n")
+	w.WriteString(frames.String())
+	w.WriteString("</g>\n</svg>\n</svg>\n")
+
+	return w.Flush()
+}
+
+// Distinct lines, drawn once as groups in the SVG's defs
+type svgLines struct {
+	palette palette
+	defs    strings.Builder
+	ids     map[string]string   // line markup to group ID
+	cache   map[*vt.Cell]string // group IDs of lines shared by frames
+}
+
+// id returns the ID of the group drawing line, or "" for a blank line.
+func (l *svgLines) id(line vt.Line) string {
+	if len(line.Cells) == 0 {
+		return ""
+	}
+	if id, ok := l.cache[&line.Cells[0]]; ok {
+		return id
+	}
+
+	markup := svgLine(line, l.palette)
+	id, ok := l.ids[markup]
+	if !ok && markup != "" {
+		id = "l" + strconv.Itoa(len(l.ids))
+		l.ids[markup] = id
+		fmt.Fprintf(&l.defs, `<g id="%s">%s</g>`+"\n", id, markup)
+	}
+	l.cache[&line.Cells[0]] = id
+	return id
+}
+
+// Appearance of a run of text
+type svgStyle struct {
+	fg    color.RGBA
+	flags vt.Flags
+}
+
+// svgLine returns the markup drawing a line at y=0: rectangles for backgrounds
+// and text elements for runs of characters of the same style.
+func svgLine(line vt.Line, pal palette) string {
+	var b strings.Builder
+	cells := line.Cells
+
+	// Backgrounds
+	for x := 0; x < len(cells); {
+		_, bg := pal.resolve(cells[x].Attr)
+		end := x + 1
+		for end < len(cells) {
+			if _, next := pal.resolve(cells[end].Attr); next != bg {
+				break
+			}
+			end++
+		}
+		if bg != pal.bg {
+			fmt.Fprintf(&b, `<rect x="%s" width="%s" height="%d" fill="%s"/>`,
+				svgNumber(float64(x)*svgCellWidth), svgNumber(float64(end-x)*svgCellWidth), svgLineHeight, hexColor(bg))
+		}
+		x = end
+	}
+
+	// Text, with wide characters in runs of their own
+	for x := 0; x < len(cells); {
+		style := svgCellStyle(cells[x], pal)
+		end := x + 1
+		if end < len(cells) && cells[end].Rune == 0 {
+			end++
+		} else {
+			for end < len(cells) && svgCellStyle(cells[end], pal) == style &&
+				!(end+1 < len(cells) && cells[end+1].Rune == 0) {
+				end++
+			}
+		}
+		svgText(&b, cells[x:end], x, style, pal)
+		x = end
+	}
+
+	return b.String()
+}
+
+func svgCellStyle(cell vt.Cell, pal palette) svgStyle {
+	fg, _ := pal.resolve(cell.Attr)
+	return svgStyle{fg: fg, flags: cell.Attr.Flags & (vt.Bold | vt.Italic | vt.Underline | vt.Strikethrough | vt.Hidden)}
+}
+
+// svgText writes a text element for a run of cells starting at column x.
+func svgText(b *strings.Builder, cells []vt.Cell, x int, style svgStyle, pal palette) {
+	if style.flags&vt.Hidden != 0 {
+		return
+	}
+
+	var runes []string
+	for _, cell := range cells {
+		if cell.Rune != 0 {
+			runes = append(runes, string(cell.Rune)+cell.Combining)
+		}
+	}
+	columns := len(cells)
+
+	// Leave out blanks at the ends of undecorated runs
+	if style.flags&(vt.Underline|vt.Strikethrough) == 0 {
+		for len(runes) != 0 && runes[0] == " " {
+			runes = runes[1:]
+			x++
+			columns--
+		}
+		for len(runes) != 0 && runes[len(runes)-1] == " " {
+			runes = runes[:len(runes)-1]
+			columns--
+		}
+	}
+	if len(runes) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, `<text x="%s" y="%d"`, svgNumber(float64(x)*svgCellWidth), svgBaseline)
+	if len(runes) > 1 {
+		// Keep the text on the grid whatever the font's advance
+		fmt.Fprintf(b, ` textLength="%s"`, svgNumber(float64(columns)*svgCellWidth))
+	}
+	if style.fg != pal.fg {
+		fmt.Fprintf(b, ` fill="%s"`, hexColor(style.fg))
+	}
+	if style.flags&vt.Bold != 0 {
+		b.WriteString(` font-weight="bold"`)
+	}
+	if style.flags&vt.Italic != 0 {
+		b.WriteString(` font-style="italic"`)
+	}
+	var decorations []string
+	if style.flags&vt.Underline != 0 {
+		decorations = append(decorations, "underline")
+	}
+	if style.flags&vt.Strikethrough != 0 {
+		decorations = append(decorations, "line-through")
+	}
+	if len(decorations) != 0 {
+		fmt.Fprintf(b, ` text-decoration="%s"`, strings.Join(decorations, " "))
+	}
+	b.WriteString(">")
+	xml.EscapeText(b, []byte(strings.Join(runes, "")))
+	b.WriteString("</text>")
+}
+
+// svgCursor returns the markup drawing a block cursor over column x of line y.
+func svgCursor(line vt.Line, x, y int, pal palette) string {
+	var b strings.Builder
+	cell := line.Cells[x]
+	columns := 1
+	if x+1 < len(line.Cells) && line.Cells[x+1].Rune == 0 {
+		columns = 2
+	}
+
+	fmt.Fprintf(&b, `<rect x="%s" y="%d" width="%s" height="%d" fill="%s"/>`,
+		svgNumber(float64(x)*svgCellWidth), y*svgLineHeight, svgNumber(float64(columns)*svgCellWidth), svgLineHeight, hexColor(pal.fg))
+	if cell.Rune != ' ' && cell.Rune != 0 {
+		fmt.Fprintf(&b, `<text x="%s" y="%d" fill="%s">`, svgNumber(float64(x)*svgCellWidth), y*svgLineHeight+svgBaseline, hexColor(pal.bg))
+		xml.EscapeText(&b, []byte(string(cell.Rune)+cell.Combining))
+		b.WriteString("</text>")
+	}
+	return b.String()
+}
+
+// svgNumber formats a length, rounded to hundredths of a pixel.
+func svgNumber(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
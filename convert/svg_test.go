@@ -0,0 +1,97 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"encoding/xml"
+	"image/color"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wk-y/asciicast2script/vt"
+)
+
+func TestThemePalette(t *testing.T) {
+	p := themePalette(map[string]string{
+		"fg":      "#abc",
+		"bg":      "#010203",
+		"palette": "#000000:#110000:#220000:#330000:#440000:#550000:#660000:#770000",
+	})
+	if p.fg != (color.RGBA{0xaa, 0xbb, 0xcc, 0xff}) || p.bg != (color.RGBA{1, 2, 3, 0xff}) {
+		t.Errorf("Wrong colors: %v, %v", p.fg, p.bg)
+	}
+	if p.ansi[1] != (color.RGBA{0x11, 0, 0, 0xff}) || p.ansi[9] != p.ansi[1] {
+		t.Errorf("Wrong palette: %v", p.ansi)
+	}
+
+	fg, bg := p.resolve(vt.Attr{FG: vt.IndexedColor(1), BG: vt.IndexedColor(196), Flags: vt.Bold | vt.Inverse})
+	if fg != (color.RGBA{0xff, 0, 0, 0xff}) || bg != p.ansi[9] {
+		t.Errorf("Wrong resolved colors: %v, %v", fg, bg)
+	}
+
+	// Invalid fields fall back to the default palette, valid ones are kept
+	p = themePalette(map[string]string{"fg": "white", "bg": "#010203", "palette": "#000000:#ffffff"})
+	if p.fg != defaultPalette.fg || p.bg != (color.RGBA{1, 2, 3, 0xff}) || p.ansi != defaultPalette.ansi {
+		t.Errorf("Wrong fallback colors: %v, %v, %v", p.fg, p.bg, p.ansi)
+	}
+	p = themePalette(map[string]string{"palette": "#000000:#110000:#220000:#330000:#440000:#550000:#660000:bad"})
+	if p.ansi != defaultPalette.ansi {
+		t.Errorf("Palette with an invalid color not replaced: %v", p.ansi)
+	}
+}
+
+func TestAsciicastToSVG(t *testing.T) {
+	cast := `{"version": 2, "width": 10, "height": 2, "idle_time_limit": 1}` + "\n" +
+		`[0.5, "o", "a"]` + "\n" +
+		`[0.51, "o", "b"]` + "\n" +
+		`[0.52, "o", "c"]` + "\n" +
+		`[10, "o", "\u001b[31m<&>\u001b[0m"]` + "\n" +
+		`[10.5, "i", "x"]` + "\n"
+
+	cases := []struct {
+		opts      AnimationOptions
+		keyframes []string
+	}{
+		{AnimationOptions{MaxFPS: 10, FinalDelay: 0.48}, []string{"0.000%", "25.000%", "76.000%", "100%"}},
+		{AnimationOptions{IdleTimeLimit: 0.25, FinalDelay: 0.48}, []string{"0.000%", "25.000%", "26.000%", "27.000%", "52.000%", "100%"}},
+	}
+
+	for _, c := range cases {
+		var svg strings.Builder
+		if err := AsciicastToSVG(strings.NewReader(cast), &svg, c.opts); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		decoder := xml.NewDecoder(strings.NewReader(svg.String()))
+		var text strings.Builder
+		for {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%+v: Invalid SVG: %v\n%s", c.opts, err, svg.String())
+			}
+			if data, ok := token.(xml.CharData); ok {
+				text.Write(data)
+			}
+		}
+		if !strings.Contains(text.String(), "abc") || !strings.Contains(text.String(), "<&>") {
+			t.Errorf("%+v: Text missing from SVG:\n%s", c.opts, svg.String())
+		}
+
+		var keyframes []string
+		for _, line := range strings.Split(svg.String(), "\n") {
+			if percent, _, ok := strings.Cut(line, " { transform"); ok {
+				keyframes = append(keyframes, percent)
+			}
+		}
+		if !reflect.DeepEqual(keyframes, c.keyframes) {
+			t.Errorf("%+v: Wrong keyframes:\nExpected: %v\nActual:   %v", c.opts, c.keyframes, keyframes)
+		}
+	}
+}
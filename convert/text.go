@@ -0,0 +1,87 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/vt"
+)
+
+// AsciicastToText writes a plain text transcript of an asciicast.
+//
+// The output is replayed through a terminal emulator, so escape sequences,
+// cursor movement and redrawn lines are resolved to what was last on screen.
+// The transcript is the lines that scrolled off the top of the screen followed
+// by the final screen, without trailing blanks or empty lines at the end.
+// Requests to clear the scrollback are ignored, so clearing the screen only
+// drops what was on it.
+func AsciicastToText(cast io.Reader, text io.Writer, opts TextOptions) error {
+	reader, err := asciicast.NewReader(cast)
+	if err != nil {
+		return err
+	}
+
+	term := vt.NewForHeader(reader.Header())
+	term.KeepScrollback = true
+	unescape := byteUnescaper(reader.Header())
+
+	for {
+		event, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		if event.Code == asciicast.OutputEvent {
+			event.Data = unescape(event.Data)
+		}
+		if err := term.Apply(event); err != nil {
+			return err
+		}
+	}
+
+	lines := slices.Concat(term.Scrollback(), term.Lines())
+	return writeTranscript(text, lines, opts.Unwrap)
+}
+
+func writeTranscript(text io.Writer, lines []vt.Line, unwrap bool) error {
+	var transcript []string
+	var wrapped strings.Builder
+	for _, line := range lines {
+		if unwrap && line.Wrapped {
+			// The line is full, so keep trailing spaces
+			for _, cell := range line.Cells {
+				if cell.Rune != 0 {
+					wrapped.WriteRune(cell.Rune)
+					wrapped.WriteString(cell.Combining)
+				}
+			}
+			continue
+		}
+		transcript = append(transcript, wrapped.String()+line.String())
+		wrapped.Reset()
+	}
+	if wrapped.Len() != 0 {
+		transcript = append(transcript, strings.TrimRight(wrapped.String(), " "))
+	}
+
+	for len(transcript) != 0 && transcript[len(transcript)-1] == "" {
+		transcript = transcript[:len(transcript)-1]
+	}
+
+	w := bufio.NewWriter(text)
+	for _, line := range transcript {
+		w.WriteString(line)
+		w.WriteByte('\n')
+	}
+	return w.Flush()
+}
@@ -0,0 +1,51 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAsciicastToText(t *testing.T) {
+	cast := `{"version": 2, "width": 10, "height": 3}` + "\n" +
+		`[0.1, "o", "$ make\r\n"]` + "\n" +
+		`[0.2, "o", "[  ] 0%\r"]` + "\n" +
+		`[0.3, "o", "[# ] 50%\r[##] 100%\r\n"]` + "\n" +
+		`[0.4, "i", "ignored"]` + "\n" +
+		`[0.5, "o", "\u001b[1;31merror:\u001b[0m it went wrong\r\n"]` + "\n" +
+		`[0.6, "o", "\u001b[?1049hfull screen\u001b[?1049l"]` + "\n" +
+		`[0.7, "o", "$ "]` + "\n"
+
+	cases := []struct {
+		opts TextOptions
+		text string
+	}{
+		{TextOptions{}, "$ make\n[##] 100%\nerror: it\nwent wrong\n$\n"},
+		{TextOptions{Unwrap: true}, "$ make\n[##] 100%\nerror: it went wrong\n$\n"},
+	}
+
+	for _, c := range cases {
+		var text strings.Builder
+		if err := AsciicastToText(strings.NewReader(cast), &text, c.opts); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if text.String() != c.text {
+			t.Errorf("%+v: Wrong text:\nExpected: %q\nActual:   %q", c.opts, c.text, text.String())
+		}
+	}
+
+	// clear(1) also clears the scrollback
+	cleared := `{"version": 2, "width": 10, "height": 2}` + "\n" +
+		`[0.1, "o", "one\r\ntwo\r\nthree\r\n"]` + "\n" +
+		`[0.2, "o", "\u001b[H\u001b[2J\u001b[3J$ "]` + "\n"
+	var text strings.Builder
+	if err := AsciicastToText(strings.NewReader(cleared), &text, TextOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "one\ntwo\n$\n"; text.String() != expected {
+		t.Errorf("Wrong text after clear:\nExpected: %q\nActual:   %q", expected, text.String())
+	}
+}
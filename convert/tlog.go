@@ -0,0 +1,191 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/tlog"
+)
+
+// TlogToAsciicast converts a tlog recording into an asciicast.
+// The log must hold the messages of a single session, use
+// tlog.ReadSessions and TlogSessionToAsciicast for logs of several sessions.
+func TlogToAsciicast(log io.Reader, cast io.Writer, opts AsciicastOptions) error {
+	if err := checkTlogCharset(opts); err != nil {
+		return err
+	}
+
+	sessions, err := tlog.ReadSessions(log)
+	if err != nil {
+		return err
+	}
+
+	switch len(sessions) {
+	case 0:
+		return fmt.Errorf("no tlog messages")
+	case 1:
+		return TlogSessionToAsciicast(sessions[0], cast, opts)
+	default:
+		return fmt.Errorf("log holds %d recordings", len(sessions))
+	}
+}
+
+// Tlog recordings are always UTF-8
+func checkTlogCharset(opts AsciicastOptions) error {
+	if opts.Charset != "" {
+		return fmt.Errorf("tlog recordings are UTF-8, charset %q can not be used", opts.Charset)
+	}
+	return nil
+}
+
+// TlogSessionToAsciicast converts a tlog session into an asciicast.
+//
+// The window size at the start of the session gives the terminal size,
+// or the size from opts if there is none. The session's terminal type and user
+// go into the TERM and USER variables of the header's env.
+func TlogSessionToAsciicast(session tlog.Session, cast io.Writer, opts AsciicastOptions) error {
+	if err := checkTlogCharset(opts); err != nil {
+		return err
+	}
+
+	events, err := session.Events()
+	if err != nil {
+		return err
+	}
+
+	header := asciicast.HeaderV2{
+		Version: 2,
+		Width:   opts.width(),
+		Height:  opts.height(),
+		Env:     map[string]string{},
+	}
+	if session.Term != "" {
+		header.Env["TERM"] = session.Term
+	}
+	if session.User != "" {
+		header.Env["USER"] = session.User
+	}
+
+	// The first window size is the initial terminal size if no data came before it
+	for i, event := range events {
+		if event.Type == tlog.WindowEvent {
+			header.Width, header.Height = event.Width, event.Height
+			events = slices.Delete(events, i, i+1)
+			break
+		}
+		if event.Data != "" {
+			break
+		}
+	}
+
+	// The data is already UTF-8, apart from bytes which were invalid
+	encoder, err := newDataEncoder(AsciicastOptions{EscapeBytes: opts.EscapeBytes, OnReplacedBytes: opts.OnReplacedBytes})
+	if err != nil {
+		return err
+	}
+
+	writer, err := asciicast.NewWriter(cast, opts.version(), encoder.header(asciicast.HeaderV2Iface{Header: header}))
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		time := float64(event.Pos) / 1000
+
+		var acEvent asciicast.Event
+		switch event.Type {
+		case tlog.WindowEvent:
+			acEvent = asciicast.NewResizeEvent(time, event.Width, event.Height)
+		case tlog.InputEvent:
+			if opts.SkipInput {
+				continue
+			}
+			acEvent = asciicast.Event{Time: time, Code: asciicast.InputEvent, Data: event.Data}
+		case tlog.OutputEvent:
+			acEvent = asciicast.Event{Time: time, Code: asciicast.OutputEvent, Data: event.Data}
+		}
+
+		acEvent, ok, err := encoder.encode(acEvent)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := writer.Write(acEvent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AsciicastToTlog converts an asciicast into a tlog recording of the session described by metadata.
+//
+// If metadata has no terminal type or user, they are taken from the asciicast's header.
+// Times are rounded to milliseconds. Only input, output and resize events are kept.
+func AsciicastToTlog(cast io.Reader, log io.Writer, metadata tlog.Metadata, opts TlogOptions) error {
+	reader, err := asciicast.NewReader(cast)
+	if err != nil {
+		return err
+	}
+
+	header := reader.Header()
+	term, _ := header.Term()
+	metadata.Term = cmp.Or(metadata.Term, term)
+	metadata.User = cmp.Or(metadata.User, header.Env()["USER"])
+
+	unescape := byteUnescaper(header)
+
+	writer := tlog.NewWriter(log, metadata)
+	if err := writer.Write(tlog.Event{Type: tlog.WindowEvent, Width: header.Width(), Height: header.Height()}); err != nil {
+		return err
+	}
+
+	var pos int64
+	for {
+		acEvent, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		// Positions must not go backwards
+		pos = max(pos, int64(math.Round(acEvent.Time*1000)))
+		event := tlog.Event{Pos: pos, Data: unescape(acEvent.Data)}
+
+		switch acEvent.Code {
+		case asciicast.OutputEvent:
+			event.Type = tlog.OutputEvent
+		case asciicast.InputEvent:
+			if opts.SkipInput {
+				continue
+			}
+			event.Type = tlog.InputEvent
+		case asciicast.ResizeEvent:
+			cols, rows, err := acEvent.Resize()
+			if err != nil {
+				return err
+			}
+			event = tlog.Event{Pos: pos, Type: tlog.WindowEvent, Width: cols, Height: rows}
+		default:
+			continue
+		}
+
+		if err := writer.Write(event); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,45 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/tlog"
+)
+
+func TestTlogRoundtrip(t *testing.T) {
+	cast := `{"version": 2, "width": 100, "height": 30, "env": {"TERM": "xterm", "USER": "alice"}}` + "\n" +
+		`[0.5, "o", "h\u00e9llo"]` + "\n" +
+		`[0.75, "i", "q"]` + "\n" +
+		`[1.0, "r", "80x24"]` + "\n" +
+		`[1.5, "o", "world"]` + "\n"
+
+	var log bytes.Buffer
+	if err := AsciicastToTlog(strings.NewReader(cast), &log, tlog.Metadata{Host: "a", Rec: "r1"}, TlogOptions{}); err != nil {
+		t.Fatalf("Error converting to tlog: %v", err)
+	}
+
+	var castOut bytes.Buffer
+	if err := TlogToAsciicast(&log, &castOut, AsciicastOptions{}); err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	header := expectEvents(t, &castOut, []asciicast.Event{
+		{Time: 0.5, Code: asciicast.OutputEvent, Data: "héllo"},
+		{Time: 0.75, Code: asciicast.InputEvent, Data: "q"},
+		asciicast.NewResizeEvent(1, 80, 24),
+		{Time: 1.5, Code: asciicast.OutputEvent, Data: "world"},
+	})
+	if header.Width() != 100 || header.Height() != 30 {
+		t.Errorf("Wrong size %dx%d", header.Width(), header.Height())
+	}
+	if term, _ := header.Term(); term != "xterm" || header.Env()["USER"] != "alice" {
+		t.Errorf("Wrong env %v", header.Env())
+	}
+}
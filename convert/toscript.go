@@ -0,0 +1,145 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/internal/timing"
+	"github.com/wk-y/asciicast2script/script"
+)
+
+// AsciicastToScript converts the asciicast read from cast into a typescript
+// and a timingfile.
+//
+// If the exit status of the recording is known, from an exit event or the
+// "script_info" header field, the typescript ends with a "Script done on" trailer,
+// unless the asciicast has no timestamp to date it with.
+// Markers are written as "MARKER" info entries, which scriptreplay ignores.
+func AsciicastToScript(cast io.Reader, typescript, timingfile io.Writer, opts ScriptOptions) error {
+	return AsciicastToScriptStreams(cast, typescript, nil, timingfile, opts)
+}
+
+// AsciicastToScriptStreams is like AsciicastToScript, but writes input to a
+// separate typescript like script's --log-in.
+// If input is nil, input is written to output.
+func AsciicastToScriptStreams(cast io.Reader, output, input, timingfile io.Writer, opts ScriptOptions) error {
+	reader, err := asciicast.NewReader(cast)
+	if err != nil {
+		return err
+	}
+
+	writer := script.NewMultiWriter(output, input, timingfile)
+	writer.Classic = opts.Classic
+
+	header := reader.Header()
+	sHeader := ScriptHeader(header)
+	if err := writer.WriteHeader(sHeader); err != nil {
+		return err
+	}
+
+	// These entries describe the end of the recording, so they are written last
+	info := ScriptInfo(header)
+	// A malformed exit status is ignored, like other malformed info entries
+	status, err := strconv.Atoi(info[script.InfoExitCode])
+	exitKnown := err == nil
+	delete(info, script.InfoExitCode)
+	delete(info, script.InfoDuration)
+
+	for _, sEvent := range info.Events() {
+		if err := writer.Write(sEvent); err != nil {
+			return err
+		}
+	}
+
+	unescape := byteUnescaper(header)
+
+	// Convert events
+	var delta timing.Delta
+	var finalTime float64
+	for {
+		acEvent, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		finalTime = max(finalTime, acEvent.Time)
+
+		sEvent := script.Event{Data: unescape(acEvent.Data)}
+
+		var ignore bool
+		switch acEvent.Code {
+		case asciicast.InputEvent:
+			sEvent.Code = script.InputCode
+			ignore = opts.SkipInput
+		case asciicast.OutputEvent:
+			sEvent.Code = script.OutputCode
+		case asciicast.ResizeEvent:
+			cols, rows, err := acEvent.Resize()
+			if err != nil {
+				return err
+			}
+			sEvent = script.NewWinchEvent(0, rows, cols)
+		case asciicast.MarkerEvent:
+			label, err := acEvent.Marker()
+			if err != nil {
+				return err
+			}
+			sEvent = scriptMarker(0, label)
+		case asciicast.ExitEvent:
+			if status, err = acEvent.ExitStatus(); err != nil {
+				return err
+			}
+			exitKnown = true
+			ignore = true
+		default:
+			ignore = true
+		}
+
+		if ignore {
+			continue
+		}
+
+		sEvent.ElapsedSeconds = delta.Elapsed(acEvent.Time)
+		if err := writer.Write(sEvent); err != nil {
+			return err
+		}
+	}
+
+	// The recording may go on after the last event
+	duration := finalTime
+	if headerDuration, ok := header.Duration(); ok {
+		duration = max(duration, headerDuration)
+	}
+
+	end := script.Info{script.InfoDuration: fmt.Sprintf("%f", duration)}
+	if exitKnown {
+		end[script.InfoExitCode] = strconv.Itoa(status)
+	}
+
+	for _, sEvent := range end.Events() {
+		if err := writer.Write(sEvent); err != nil {
+			return err
+		}
+	}
+
+	// The trailer is dated, so it needs the start time
+	if !exitKnown || sHeader.Start.IsZero() {
+		return nil
+	}
+
+	trailer := script.Trailer{
+		Done:     sHeader.Start.Add(time.Duration(duration * float64(time.Second))),
+		ExitCode: status,
+	}
+	return writer.WriteTrailer(trailer)
+}
@@ -0,0 +1,169 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"io"
+	"time"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/ttyrec"
+)
+
+// TtyrecToAsciicast converts a ttyrec recording into an asciicast.
+//
+// The asciicast's timestamp is the whole second of the first frame, and the
+// first event comes after the rest of that second, so frames keep their times
+// to the microsecond. The recording has no terminal size, so the size from
+// opts is used. Characters split between frames are joined in the later event.
+func TtyrecToAsciicast(recording io.Reader, cast io.Writer, opts AsciicastOptions) error {
+	var first ttyrec.Event
+	err := first.Take(recording)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	empty := err == io.EOF
+
+	encoder, err := newDataEncoder(opts)
+	if err != nil {
+		return err
+	}
+
+	start := time.Unix(first.Time.Unix(), 0)
+	header := asciicast.HeaderV2{
+		Version: 2,
+		Width:   opts.width(),
+		Height:  opts.height(),
+	}
+	if !empty {
+		timestamp := start.Unix()
+		header.Timestamp = &timestamp
+	}
+
+	writer, err := asciicast.NewWriter(cast, opts.version(), encoder.header(asciicast.HeaderV2Iface{Header: header}))
+	if err != nil || empty {
+		return err
+	}
+
+	var previous float64
+	for event := first; ; {
+		// Clock adjustments during recording can make time go backwards
+		t := max(event.Time.Sub(start).Seconds(), previous)
+		previous = t
+
+		acEvent, ok, err := encoder.encode(asciicast.Event{Time: t, Code: asciicast.OutputEvent, Data: event.Data})
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := writer.Write(acEvent); err != nil {
+				return err
+			}
+		}
+
+		if err := event.Take(recording); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+	}
+
+	rest, err := encoder.flush(previous)
+	if err != nil {
+		return err
+	}
+	for _, acEvent := range rest {
+		if err := writer.Write(acEvent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AsciicastToTtyrec converts the output of an asciicast into a ttyrec recording.
+//
+// Frames are timestamped from the asciicast's start time, or the unix epoch if it has none.
+func AsciicastToTtyrec(cast io.Reader, recording io.Writer) error {
+	reader, err := asciicast.NewReader(cast)
+	if err != nil {
+		return err
+	}
+
+	start := time.Unix(0, 0)
+	if timestamp, ok := reader.Header().Timestamp(); ok {
+		start = time.Unix(timestamp, 0).UTC()
+	}
+	unescape := byteUnescaper(reader.Header())
+
+	for {
+		acEvent, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		if acEvent.Code != asciicast.OutputEvent {
+			continue
+		}
+
+		event := ttyrec.Event{
+			Data: unescape(acEvent.Data),
+			Time: start.Add(time.Duration(acEvent.Time * float64(time.Second))),
+		}
+		if err := event.Write(recording); err != nil {
+			return err
+		}
+	}
+}
+
+// TtyrecToScript converts a ttyrec recording into a typescript and a timingfile.
+//
+// The recording goes through an asciicast with its bytes escaped, so the
+// typescript has exactly the recorded bytes. The terminal size recorded in the
+// typescript is taken from castOpts, as in TtyrecToAsciicast.
+func TtyrecToScript(recording io.Reader, typescript, timingfile io.Writer, castOpts AsciicastOptions, opts ScriptOptions) error {
+	castOpts.EscapeBytes = true
+	castOpts.Charset = ""
+	return viaAsciicast(func(cast io.Writer) error {
+		return TtyrecToAsciicast(recording, cast, castOpts)
+	}, func(cast io.Reader) error {
+		return AsciicastToScript(cast, typescript, timingfile, opts)
+	})
+}
+
+// ScriptToTtyrec converts the output of a typescript and timingfile into a
+// ttyrec recording, keeping the typescript's bytes exactly.
+// The start date of the typescript is read with the Location and
+// HeaderDialects of castOpts.
+func ScriptToTtyrec(typescript, timingfile io.Reader, recording io.Writer, castOpts AsciicastOptions) error {
+	castOpts.EscapeBytes = true
+	castOpts.Charset = ""
+	return viaAsciicast(func(cast io.Writer) error {
+		return ScriptToAsciicast(typescript, timingfile, cast, castOpts)
+	}, func(cast io.Reader) error {
+		return AsciicastToTtyrec(cast, recording)
+	})
+}
+
+// viaAsciicast runs a conversion to asciicast and one from asciicast concurrently,
+// connected by a pipe.
+func viaAsciicast(to func(io.Writer) error, from func(io.Reader) error) error {
+	r, w := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.CloseWithError(to(w))
+	}()
+
+	err := from(r)
+	// Stop the first conversion if the second one failed before reading everything
+	r.CloseWithError(err)
+	<-done
+	return err
+}
@@ -0,0 +1,69 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"github.com/wk-y/asciicast2script/ttyrec"
+)
+
+func TestTtyrecRoundtrip(t *testing.T) {
+	cast := `{"version": 3, "term": {"cols": 80, "rows": 24}, "timestamp": 1743536096}` + "\n" +
+		`[0.5, "o", "hello"]` + "\n" +
+		`[0.25, "i", "x"]` + "\n" +
+		`[1.0, "o", "world"]` + "\n"
+
+	var recording bytes.Buffer
+	if err := AsciicastToTtyrec(strings.NewReader(cast), &recording); err != nil {
+		t.Fatalf("Error converting to ttyrec: %v", err)
+	}
+
+	var castOut bytes.Buffer
+	if err := TtyrecToAsciicast(&recording, &castOut, AsciicastOptions{Version: 3}); err != nil {
+		t.Fatalf("Error converting to asciicast: %v", err)
+	}
+
+	header := expectEvents(t, &castOut, []asciicast.Event{
+		{Time: 0.5, Code: asciicast.OutputEvent, Data: "hello"},
+		{Time: 1.75, Code: asciicast.OutputEvent, Data: "world"},
+	})
+	if timestamp, _ := header.Timestamp(); timestamp != 1743536096 {
+		t.Errorf("Wrong timestamp %d", timestamp)
+	}
+}
+
+func TestTtyrecScriptRoundtrip(t *testing.T) {
+	var recording bytes.Buffer
+	for _, event := range []ttyrec.Event{
+		{Time: time.Unix(1743536096, 250000000), Data: "hello \xe9!"},
+		{Time: time.Unix(1743536097, 0), Data: "\xff world"},
+	} {
+		if err := event.Write(&recording); err != nil {
+			t.Fatal(err)
+		}
+	}
+	original := bytes.Clone(recording.Bytes())
+
+	var typescript, timingfile bytes.Buffer
+	if err := TtyrecToScript(&recording, &typescript, &timingfile, AsciicastOptions{}, ScriptOptions{}); err != nil {
+		t.Fatalf("Error converting to script: %v", err)
+	}
+	if !strings.Contains(typescript.String(), "hello \xe9!\xff world") {
+		t.Errorf("Wrong typescript:\n%q", typescript.String())
+	}
+
+	var recordingOut bytes.Buffer
+	if err := ScriptToTtyrec(&typescript, &timingfile, &recordingOut, AsciicastOptions{}); err != nil {
+		t.Fatalf("Error converting to ttyrec: %v", err)
+	}
+	if !bytes.Equal(recordingOut.Bytes(), original) {
+		t.Errorf("Wrong ttyrec:\n%q\nExpected:\n%q", recordingOut.Bytes(), original)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/wk-y/asciicast2script
 
 go 1.23.4
+
+require golang.org/x/text v0.21.0
@@ -0,0 +1,70 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package castinput opens the recording rendered by the cast2* commands:
+// an asciicast, or a typescript and timing file converted to one on the fly.
+package castinput
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/wk-y/asciicast2script/convert"
+)
+
+// Input is where a command reads its recording from when no asciicast is given.
+type Input struct {
+	TypescriptPath string
+	TimingfilePath string
+	Width, Height  int    // terminal size if the typescript doesn't record it
+	Charset        string // character set of the typescript
+}
+
+// RegisterFlags adds flags setting the fields of in to fs.
+func (in *Input) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&in.TypescriptPath, "typescript", "typescript", "input typescript file, when no asciicast is given")
+	fs.StringVar(&in.TimingfilePath, "timingfile", "timingfile", "input timing file, when no asciicast is given")
+	fs.IntVar(&in.Width, "cols", 80, "terminal width if the typescript doesn't record it")
+	fs.IntVar(&in.Height, "rows", 24, "terminal height if the typescript doesn't record it")
+	fs.StringVar(&in.Charset, "charset", "", "character set of the typescript, ex. ISO-8859-1 or Shift_JIS (default UTF-8)")
+}
+
+// Open opens the asciicast at path, or standard input if path is "-".
+// If path is "", the typescript and timing file are converted to an asciicast
+// as it is read. Conversion errors are returned by the reader's Read.
+func (in *Input) Open(path string) (io.ReadCloser, error) {
+	switch path {
+	case "-":
+		return io.NopCloser(os.Stdin), nil
+	case "":
+	default:
+		return os.Open(path)
+	}
+
+	typescript, err := os.Open(in.TypescriptPath)
+	if err != nil {
+		return nil, err
+	}
+
+	timing, err := os.Open(in.TimingfilePath)
+	if err != nil {
+		typescript.Close()
+		return nil, err
+	}
+
+	opts := convert.AsciicastOptions{
+		Width:   in.Width,
+		Height:  in.Height,
+		Charset: in.Charset,
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		defer typescript.Close()
+		defer timing.Close()
+		w.CloseWithError(convert.ScriptToAsciicast(typescript, timing, w, opts))
+	}()
+	return r, nil
+}
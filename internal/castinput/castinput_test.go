@@ -0,0 +1,67 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package castinput
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenTypescript(t *testing.T) {
+	dir := t.TempDir()
+	in := Input{
+		TypescriptPath: filepath.Join(dir, "typescript"),
+		TimingfilePath: filepath.Join(dir, "timingfile"),
+		Width:          100,
+		Height:         30,
+	}
+	if err := os.WriteFile(in.TypescriptPath, []byte("Script started on Tue Apr  1 12:34:56 2025\nhello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(in.TimingfilePath, []byte("O 0.500000 5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cast, err := in.Open("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer cast.Close()
+
+	data, err := io.ReadAll(cast)
+	if err != nil {
+		t.Fatalf("Error reading asciicast: %v", err)
+	}
+	if !strings.HasPrefix(string(data), `{"version":2,"width":100,"height":30,`) || !strings.HasSuffix(string(data), "\n[0.5,\"o\",\"hello\"]\n") {
+		t.Errorf("Wrong asciicast:\n%s", data)
+	}
+}
+
+func TestOpenConversionError(t *testing.T) {
+	dir := t.TempDir()
+	in := Input{
+		TypescriptPath: filepath.Join(dir, "typescript"),
+		TimingfilePath: filepath.Join(dir, "timingfile"),
+	}
+	if err := os.WriteFile(in.TypescriptPath, []byte("not a typescript\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(in.TimingfilePath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cast, err := in.Open("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer cast.Close()
+
+	if _, err := io.ReadAll(cast); err == nil {
+		t.Errorf("Expected error for an invalid typescript")
+	}
+}
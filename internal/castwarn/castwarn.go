@@ -0,0 +1,24 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package castwarn prints the warnings of the *2asciicast commands.
+package castwarn
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ReplacedBytes returns a function for convert.AsciicastOptions.OnReplacedBytes
+// warning on w that bytes which aren't valid UTF-8 were replaced.
+// The warning is printed once, however many conversions the function is used for.
+func ReplacedBytes(w io.Writer) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			fmt.Fprintln(w, "warning: bytes which aren't valid UTF-8 were replaced with U+FFFD, use -escape-bytes to keep them")
+		})
+	}
+}
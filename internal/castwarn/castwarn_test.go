@@ -0,0 +1,21 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package castwarn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplacedBytesOnce(t *testing.T) {
+	var out strings.Builder
+	warn := ReplacedBytes(&out)
+	warn()
+	warn()
+
+	if strings.Count(out.String(), "warning:") != 1 {
+		t.Errorf("Expected one warning, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,220 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package font is a small monospace bitmap font for drawing terminal screens.
+//
+// Glyphs are 5x7 pixels with 2 rows for descenders, in a cell of Width x Height
+// pixels. The font covers ASCII, the DEC line drawing characters, and the box
+// drawing, block and braille characters used by text user interfaces, which
+// are generated to fill the cell so they join up with their neighbors.
+package font
+
+import (
+	_ "embed"
+	"strconv"
+	"strings"
+)
+
+// Size of a character cell in pixels
+const (
+	Width  = 6
+	Height = 11
+)
+
+// Rows of lines drawn across a cell
+const (
+	UnderlineRow     = 10
+	StrikethroughRow = 5
+)
+
+// Bitmap is the pixels of a cell, one row per element with the leftmost
+// pixel in bit Width-1.
+type Bitmap [Height]uint8
+
+// Set reports whether the pixel at x, y is set.
+func (b Bitmap) Set(x, y int) bool {
+	return b[y]&(1<<(Width-1-x)) != 0
+}
+
+func (b *Bitmap) set(x, y int) {
+	b[y] |= 1 << (Width - 1 - x)
+}
+
+// Missing is drawn for characters the font doesn't have.
+var Missing = Bitmap{0, 0x3e, 0x22, 0x22, 0x22, 0x22, 0x22, 0x22, 0x22, 0x3e, 0}
+
+// Top row of the glyphs in glyphs.txt
+const glyphTop = 1
+
+//go:embed glyphs.txt
+var glyphData string
+
+var glyphs = map[rune]Bitmap{}
+
+func init() {
+	// Glyphs are a line "U+XXXX" followed by rows of '.' and '#'
+	var glyph Bitmap
+	var r rune = -1
+	var y int
+	for _, line := range strings.Split(glyphData, "\n") {
+		switch {
+		case line == "" || strings.HasPrefix(line, "# "): // comment
+			continue
+		case strings.HasPrefix(line, "U+"):
+			code, err := strconv.ParseUint(line[2:], 16, 32)
+			if err != nil {
+				panic("font: bad glyph header " + line)
+			}
+			r, y, glyph = rune(code), glyphTop, Bitmap{}
+		default:
+			if r < 0 || y >= Height {
+				panic("font: bad glyph data " + line)
+			}
+			for x, c := range line {
+				if c == '#' {
+					glyph.set(x, y)
+				}
+			}
+			y++
+			glyphs[r] = glyph
+		}
+	}
+
+	addBoxDrawing()
+	addBlocks()
+	addBraille()
+}
+
+// Glyph returns the bitmap of r.
+func Glyph(r rune) (Bitmap, bool) {
+	glyph, ok := glyphs[r]
+	return glyph, ok
+}
+
+// Arms of box drawing characters
+const (
+	up = 1 << iota
+	down
+	left
+	right
+)
+
+// Center of box drawing characters
+const (
+	boxX = 2
+	boxY = 5
+)
+
+func addBoxDrawing() {
+	// Heavy, double and dashed lines are drawn as light lines
+	ranges := []struct {
+		first, last rune
+		arms        int
+	}{
+		{0x2500, 0x2501, left | right},
+		{0x2502, 0x2503, up | down},
+		{0x2504, 0x2505, left | right},
+		{0x2506, 0x2507, up | down},
+		{0x2508, 0x2509, left | right},
+		{0x250a, 0x250b, up | down},
+		{0x250c, 0x250f, down | right},
+		{0x2510, 0x2513, down | left},
+		{0x2514, 0x2517, up | right},
+		{0x2518, 0x251b, up | left},
+		{0x251c, 0x2523, up | down | right},
+		{0x2524, 0x252b, up | down | left},
+		{0x252c, 0x2533, down | left | right},
+		{0x2534, 0x253b, up | left | right},
+		{0x253c, 0x254b, up | down | left | right},
+		{0x254c, 0x254d, left | right},
+		{0x254e, 0x254f, up | down},
+		{0x2550, 0x2550, left | right},
+		{0x2551, 0x2551, up | down},
+		{0x2552, 0x2554, down | right},
+		{0x2555, 0x2557, down | left},
+		{0x2558, 0x255a, up | right},
+		{0x255b, 0x255d, up | left},
+		{0x255e, 0x2560, up | down | right},
+		{0x2561, 0x2563, up | down | left},
+		{0x2564, 0x2566, down | left | right},
+		{0x2567, 0x2569, up | left | right},
+		{0x256a, 0x256c, up | down | left | right},
+		{0x256d, 0x256d, down | right},
+		{0x256e, 0x256e, down | left},
+		{0x256f, 0x256f, up | left},
+		{0x2570, 0x2570, up | right},
+		{0x2574, 0x2574, left},
+		{0x2575, 0x2575, up},
+		{0x2576, 0x2576, right},
+		{0x2577, 0x2577, down},
+		{0x2578, 0x2578, left},
+		{0x2579, 0x2579, up},
+		{0x257a, 0x257a, right},
+		{0x257b, 0x257b, down},
+	}
+
+	for _, rng := range ranges {
+		var glyph Bitmap
+		for x := range Width {
+			if (x <= boxX && rng.arms&left != 0) || (x >= boxX && rng.arms&right != 0) {
+				glyph.set(x, boxY)
+			}
+		}
+		for y := range Height {
+			if (y <= boxY && rng.arms&up != 0) || (y >= boxY && rng.arms&down != 0) {
+				glyph.set(boxX, y)
+			}
+		}
+		for r := rng.first; r <= rng.last; r++ {
+			glyphs[r] = glyph
+		}
+	}
+}
+
+func addBlocks() {
+	// fill returns a glyph with the pixels in the given rectangle set.
+	fill := func(x0, y0, x1, y1 int) Bitmap {
+		var glyph Bitmap
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				glyph.set(x, y)
+			}
+		}
+		return glyph
+	}
+
+	glyphs['▀'] = fill(0, 0, Width, Height/2)
+	glyphs['▐'] = fill(Width/2, 0, Width, Height)
+	glyphs['▔'] = fill(0, 0, Width, 1)
+	glyphs['▕'] = fill(Width-1, 0, Width, Height)
+	for eighths := 1; eighths <= 8; eighths++ {
+		// Lower blocks ▁ to █, and left blocks ▏ to █
+		glyphs[rune(0x2580+eighths)] = fill(0, Height-(eighths*Height+4)/8, Width, Height)
+		glyphs[rune(0x2590-eighths)] = fill(0, 0, (eighths*Width+4)/8, Height)
+	}
+
+	// Shades ░ ▒ ▓
+	for i, pattern := range [][2]uint8{{0x22, 0x08}, {0x2a, 0x15}, {0x3b, 0x2e}} {
+		var glyph Bitmap
+		for y := range Height {
+			glyph[y] = pattern[y%2]
+		}
+		glyphs[rune(0x2591+i)] = glyph
+	}
+}
+
+func addBraille() {
+	// Dots 1-3 and 7 are the left column from the top, 4-6 and 8 the right
+	dots := [8][2]int{{1, 1}, {1, 3}, {1, 5}, {3, 1}, {3, 3}, {3, 5}, {1, 7}, {3, 7}}
+	for pattern := range 256 {
+		var glyph Bitmap
+		for i, dot := range dots {
+			if pattern&(1<<i) != 0 {
+				glyph.set(dot[0], dot[1]+1)
+				glyph.set(dot[0]+1, dot[1]+1)
+			}
+		}
+		glyphs[rune(0x2800+pattern)] = glyph
+	}
+}
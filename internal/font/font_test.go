@@ -0,0 +1,52 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package font
+
+import "testing"
+
+func TestASCII(t *testing.T) {
+	for r := rune(' '); r <= '~'; r++ {
+		if _, ok := Glyph(r); !ok {
+			t.Errorf("Missing glyph for %q", r)
+		}
+	}
+
+	if _, ok := Glyph('日'); ok {
+		t.Errorf("Unexpected glyph for wide character")
+	}
+}
+
+func TestGlyph(t *testing.T) {
+	glyph, _ := Glyph('T')
+	expected := Bitmap{0, 0x3e, 0x08, 0x08, 0x08, 0x08, 0x08, 0x08, 0, 0, 0}
+	if glyph != expected {
+		t.Errorf("Wrong glyph:\nExpected: %#v\nActual:   %#v", expected, glyph)
+	}
+
+	glyph, _ = Glyph('g')
+	if !glyph.Set(1, 9) || glyph.Set(0, 1) {
+		t.Errorf("Wrong descender: %#v", glyph)
+	}
+}
+
+func TestBoxDrawing(t *testing.T) {
+	// Lines reach the edges of the cell, to join with the next cell
+	horizontal, _ := Glyph('─')
+	if !horizontal.Set(0, boxY) || !horizontal.Set(Width-1, boxY) || horizontal.Set(boxX, 0) {
+		t.Errorf("Wrong horizontal line: %#v", horizontal)
+	}
+
+	corner, _ := Glyph('┌')
+	if !corner.Set(boxX, Height-1) || !corner.Set(Width-1, boxY) || corner.Set(0, boxY) || corner.Set(boxX, 0) {
+		t.Errorf("Wrong corner: %#v", corner)
+	}
+
+	full, _ := Glyph('█')
+	for y, row := range full {
+		if row != 1<<Width-1 {
+			t.Errorf("Row %d of full block not filled: %#b", y, row)
+		}
+	}
+}
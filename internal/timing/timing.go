@@ -0,0 +1,57 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package timing converts between relative and absolute event times without drift.
+//
+// Times are carried as integer microseconds, the resolution of script's timingfiles.
+// Each time is rounded to a microsecond once, and sums and differences are
+// taken of the rounded values, so they are exact. The error of any event time
+// stays below half a microsecond however long the recording is.
+package timing
+
+import "math"
+
+// Micros is a time in microseconds.
+type Micros int64
+
+// FromSeconds rounds a time in seconds to microseconds.
+func FromSeconds(seconds float64) Micros {
+	return Micros(math.Round(seconds * 1e6))
+}
+
+// Seconds returns m in seconds.
+func (m Micros) Seconds() float64 {
+	return float64(m) / 1e6
+}
+
+// Clock sums relative times into absolute times.
+type Clock struct {
+	now Micros
+}
+
+// Advance adds elapsed seconds to the clock and returns the new absolute time.
+func (c *Clock) Advance(elapsed float64) float64 {
+	c.now += FromSeconds(elapsed)
+	return c.now.Seconds()
+}
+
+// Now returns the absolute time of the clock.
+func (c *Clock) Now() float64 {
+	return c.now.Seconds()
+}
+
+// Delta takes the differences of absolute times.
+type Delta struct {
+	previous Micros
+}
+
+// Elapsed returns the seconds since the previous time passed to Elapsed, or since zero.
+// Times going backwards give an elapsed time of zero, and the next
+// elapsed time is taken from the latest time.
+func (d *Delta) Elapsed(time float64) float64 {
+	now := max(FromSeconds(time), d.previous)
+	elapsed := now - d.previous
+	d.previous = now
+	return elapsed.Seconds()
+}
@@ -0,0 +1,36 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package timing
+
+import "testing"
+
+func TestClock(t *testing.T) {
+	var clock Clock
+	for range 1_000_000 {
+		clock.Advance(0.1)
+	}
+	if clock.Now() != 100000 {
+		t.Errorf("Expected 100000, got %f", clock.Now())
+	}
+}
+
+func TestDelta(t *testing.T) {
+	var delta Delta
+	tests := []struct {
+		time    float64
+		elapsed float64
+	}{
+		{0.5, 0.5},
+		{0.7000004, 0.2},
+		{0.6, 0},     // backwards
+		{0.75, 0.05}, // from the latest time
+	}
+
+	for _, test := range tests {
+		if elapsed := delta.Elapsed(test.time); elapsed != test.elapsed {
+			t.Errorf("Elapsed(%f) = %f, expected %f", test.time, elapsed, test.elapsed)
+		}
+	}
+}
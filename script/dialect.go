@@ -0,0 +1,122 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package script
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// HeaderDialect parses the "Script started on" line written by one variant of script.
+// Dates without a time zone are in location.
+type HeaderDialect struct {
+	Name  string
+	Parse func(header string, location *time.Location) (Header, error)
+}
+
+// HeaderParser parses header lines written by any known variant of script.
+// The zero value parses dates without a time zone as UTC.
+type HeaderParser struct {
+	// Time zone of dates written by older variants of script, which don't
+	// record the time zone or only record its abbreviation.
+	// Abbreviations other than UTC, GMT and those of Location are ignored.
+	// Defaults to UTC.
+	Location *time.Location
+
+	// Dialects for other variants of script, tried before the built-in ones.
+	Dialects []HeaderDialect
+}
+
+// Date formats used by older variants of script, which only record the start time.
+var (
+	// ctime(3), used by BSD and macOS script and by older util-linux in the C locale
+	ctimeDate = dateFormat{
+		layout: time.ANSIC,
+		regex:  `[A-Z][a-z]{2} [A-Z][a-z]{2} [ 0-9][0-9] [0-9]{2}:[0-9]{2}:[0-9]{2} [0-9]{4}`,
+	}
+
+	// strftime(3) "%c" in the en_US locale, used by older util-linux
+	localeDate = dateFormat{
+		layout: "Mon 02 Jan 2006 03:04:05 PM MST",
+		regex:  `[A-Z][a-z]{2} [0-9]{2} [A-Z][a-z]{2} [0-9]{4} [0-9]{2}:[0-9]{2}:[0-9]{2} [AP]M [A-Z]+`,
+	}
+)
+
+// Built-in dialects, tried in order after HeaderParser.Dialects
+var headerDialects = []HeaderDialect{
+	{Name: "util-linux", Parse: func(header string, _ *time.Location) (Header, error) { return ParseHeader(header) }},
+	{Name: "ctime", Parse: dateOnlyDialect(`Script started on `, ctimeDate)},
+	{Name: "util-linux-legacy", Parse: dateOnlyDialect(`Script started on `, localeDate)},
+
+	// Translated versions of the above, ex. "Skript gestartet am ...".
+	// Only dates in the formats above are recognized.
+	{Name: "localized", Parse: localizedHeader},
+	{Name: "localized-ctime", Parse: dateOnlyDialect(`\S.*? `, ctimeDate)},
+}
+
+// ParseAnyHeader parses a header line with the zero HeaderParser.
+func ParseAnyHeader(header string) (Header, error) {
+	return HeaderParser{}.Parse(header)
+}
+
+// Parse parses a header line with the first matching dialect.
+func (p HeaderParser) Parse(header string) (Header, error) {
+	location := p.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	for _, dialects := range [][]HeaderDialect{p.Dialects, headerDialects} {
+		for _, dialect := range dialects {
+			if result, err := dialect.Parse(header, location); err == nil {
+				return result, nil
+			}
+		}
+	}
+	return Header{}, fmt.Errorf("improper header structure: %q", strings.TrimSpace(header))
+}
+
+var localizedHeaderRegex = newHeaderRegex(`\S.*? `)
+
+func localizedHeader(header string, _ *time.Location) (Header, error) {
+	return parseHeaderRegex(localizedHeaderRegex, header)
+}
+
+type dateFormat struct {
+	layout string
+	regex  string
+}
+
+// Create a parser for headers made of prefix followed by a date
+func dateOnlyDialect(prefix string, format dateFormat) func(string, *time.Location) (Header, error) {
+	re := regexp.MustCompile("^" + prefix + "(" + format.regex + ")")
+	return func(header string, location *time.Location) (result Header, err error) {
+		match := re.FindStringSubmatch(header)
+		if match == nil {
+			return result, fmt.Errorf("improper header structure")
+		}
+
+		result.Start, err = parseDate(format.layout, match[1], location)
+		return result, err
+	}
+}
+
+// parseDate parses a date in location. Zone abbreviations Go doesn't
+// know are given an offset of 0, so such dates are parsed without them.
+func parseDate(layout, value string, location *time.Location) (time.Time, error) {
+	date, err := time.ParseInLocation(layout, value, location)
+	if err != nil {
+		return date, err
+	}
+
+	if zone, _ := date.Zone(); date.Location() != location && date.Location() != time.UTC && zone != "GMT" {
+		layout, _ = strings.CutSuffix(layout, " MST")
+		value = value[:strings.LastIndexByte(value, ' ')]
+		return time.ParseInLocation(layout, value, location)
+	}
+	return date, nil
+}
@@ -0,0 +1,92 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package script
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAnyHeader(t *testing.T) {
+	testCases := []struct {
+		str      string
+		start    time.Time
+		expected Header
+	}{
+		{ // BSD, macOS
+			str:   "Script started on Tue Apr  1 12:34:56 2025\n",
+			start: time.Date(2025, 4, 1, 12, 34, 56, 0, time.UTC),
+		},
+		{ // older util-linux, en_US locale
+			str:   "Script started on Tue 01 Apr 2025 12:34:56 PM UTC\n",
+			start: time.Date(2025, 4, 1, 12, 34, 56, 0, time.UTC),
+		},
+		{ // zone unknown in the default location
+			str:   "Script started on Tue 01 Apr 2025 12:34:56 PM CEST\n",
+			start: time.Date(2025, 4, 1, 12, 34, 56, 0, time.UTC),
+		},
+		{ // localized util-linux
+			str:   `Skript gestartet am 2025-04-01 12:34:56+00:00 [TERM="xterm" TTY="/dev/pts/0" COLUMNS="80" LINES="24"]`,
+			start: time.Date(2025, 4, 1, 12, 34, 56, 0, time.UTC),
+			expected: Header{
+				Term:    "xterm",
+				Tty:     "/dev/pts/0",
+				Columns: 80,
+				Lines:   24,
+			},
+		},
+		{ // localized BSD
+			str:   "Script iniciado en Tue Apr 15 09:00:00 2025\n",
+			start: time.Date(2025, 4, 15, 9, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for i, testCase := range testCases {
+		h, err := ParseAnyHeader(testCase.str)
+		if err != nil {
+			t.Fatalf("Test %d: Unexpected error: %v", i, err)
+		}
+
+		if !h.Start.Equal(testCase.start) {
+			t.Errorf("Test %d: Wrong start time (expected %v, got %v)", i, testCase.start, h.Start)
+		}
+
+		h.Start = time.Time{}
+		if h != testCase.expected {
+			t.Errorf("Test %d:\nExpected: %#v\nActual: %#v", i, testCase.expected, h)
+		}
+	}
+
+	for _, location := range []struct {
+		zone   string
+		offset int
+		start  time.Time
+	}{
+		{"CEST", 2, time.Date(2025, 4, 1, 10, 34, 56, 0, time.UTC)},
+		{"EST", -5, time.Date(2025, 4, 1, 17, 34, 56, 0, time.UTC)}, // CEST is ignored
+	} {
+		parser := HeaderParser{Location: time.FixedZone(location.zone, location.offset*60*60)}
+		h, err := parser.Parse("Script started on Tue 01 Apr 2025 12:34:56 PM CEST\n")
+		if err != nil || !h.Start.Equal(location.start) {
+			t.Errorf("%s: Wrong start time (expected %v, got %v, %v)", location.zone, location.start, h.Start, err)
+		}
+	}
+
+	if _, err := ParseAnyHeader("hello world\n"); err == nil {
+		t.Errorf("Expected error for line without a date")
+	}
+
+	custom := HeaderParser{Dialects: []HeaderDialect{{
+		Name: "custom",
+		Parse: func(header string, location *time.Location) (result Header, err error) {
+			result.Start, err = time.ParseInLocation("2006-01-02 15:04:05", strings.TrimSpace(header), location)
+			return result, err
+		},
+	}}}
+	if h, err := custom.Parse("2025-04-01 12:34:56\n"); err != nil || !h.Start.Equal(time.Date(2025, 4, 1, 12, 34, 56, 0, time.UTC)) {
+		t.Errorf("Custom dialect: wrong start time %v, %v", h.Start, err)
+	}
+}
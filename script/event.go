@@ -8,17 +8,91 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 )
 
+// Event codes of the advanced timing format
+const (
+	InputCode  = 'I'
+	OutputCode = 'O'
+	SignalCode = 'S'
+	InfoCode   = 'H'
+)
+
+// Signal name used for terminal resizes
+const SigWinch = "SIGWINCH"
+
+// Event is an entry of a timingfile.
+//
+// For input and output events, Data holds the bytes read from the typescript.
+// Signal and info events have no typescript data; Name holds the signal or
+// info name and Data holds the rest of the timing line.
 type Event struct {
 	Data           string
 	ElapsedSeconds float64
 	Code           rune
+	Name           string
+}
+
+// NewWinchEvent creates a SIGWINCH signal event for a terminal resize.
+func NewWinchEvent(elapsedSeconds float64, rows, cols int) Event {
+	return Event{
+		Data:           fmt.Sprintf("ROWS=%d COLS=%d", rows, cols),
+		ElapsedSeconds: elapsedSeconds,
+		Code:           SignalCode,
+		Name:           SigWinch,
+	}
+}
+
+// WindowSize returns the terminal size of a SIGWINCH signal event.
+func (e Event) WindowSize() (rows, cols int, err error) {
+	if e.Code != SignalCode || e.Name != SigWinch {
+		return 0, 0, fmt.Errorf("not a %s event", SigWinch)
+	}
+
+	rows, cols = -1, -1
+	for _, field := range strings.Fields(e.Data) {
+		key, value, _ := strings.Cut(field, "=")
+		var n int
+		n, err = strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid %s field %q", SigWinch, field)
+		}
+
+		switch key {
+		case "ROWS":
+			rows = n
+		case "COLS":
+			cols = n
+		}
+	}
+
+	if rows < 0 || cols < 0 {
+		return 0, 0, fmt.Errorf("missing window size in %s event %q", SigWinch, e.Data)
+	}
+
+	return rows, cols, nil
+}
+
+// HasData reports whether the event carries typescript data.
+func (e Event) HasData() bool {
+	return e.Code != SignalCode && e.Code != InfoCode
 }
 
+// Take reads the next event of timingfile and its data from typescript.
+// It returns io.EOF at the end of the timingfile, and an error wrapping
+// io.ErrUnexpectedEOF if the typescript ends before the data of the event.
 func (e *Event) Take(typescript io.Reader, timingfile *bufio.Reader) error {
+	return e.TakeMulti(typescript, typescript, timingfile)
+}
+
+// TakeMulti is like Take, but the data of input events is read from input.
+// This is used for recordings with separate input and output logs (script --log-in/--log-out).
+// Either typescript may be nil if the recording has no log for it.
+func (e *Event) TakeMulti(output, input io.Reader, timingfile *bufio.Reader) error {
 	line, err := timingfile.ReadBytes('\n')
-	if err != nil {
+	if err != nil && (err != io.EOF || len(line) == 0) {
 		return err
 	}
 
@@ -28,26 +102,37 @@ func (e *Event) Take(typescript io.Reader, timingfile *bufio.Reader) error {
 
 	var dataLen int
 	if line[0] >= 'A' && line[0] <= 'Z' {
-		e.Code, e.ElapsedSeconds, dataLen, err = parseAdvancedTiming(string(line))
+		*e, dataLen, err = parseAdvancedTiming(string(line))
 	} else {
-		e.Code = 'O'
+		*e = Event{Code: OutputCode}
 		e.ElapsedSeconds, dataLen, err = parseClassicTiming(string(line))
 	}
 	if err != nil {
 		return err
 	}
 
+	if !e.HasData() {
+		return nil
+	}
+
 	if dataLen < 0 {
 		return fmt.Errorf("negative event length in timing file")
 	}
 
+	typescript := output
+	if e.Code == InputCode {
+		typescript = input
+	}
+	if typescript == nil {
+		return fmt.Errorf("no typescript for %c event", e.Code)
+	}
+
 	buf := make([]byte, dataLen)
-	for read := 0; read < dataLen; {
-		n, err := typescript.Read(buf[read:])
-		read += n
-		if err != nil {
-			return err
+	if _, err := io.ReadFull(typescript, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("typescript truncated in %c event: %w", e.Code, io.ErrUnexpectedEOF)
 		}
+		return err
 	}
 
 	e.Data = string(buf)
@@ -55,10 +140,49 @@ func (e *Event) Take(typescript io.Reader, timingfile *bufio.Reader) error {
 	return nil
 }
 
-func parseAdvancedTiming(s string) (code rune, elapsed float64, dataLen int, err error) {
-	// todo: error if string continues past fields
-	_, err = fmt.Sscanf(s, "%c %f %d", &code, &elapsed, &dataLen)
-	return
+// SplitTimingLine splits a timing line of the form "CODE ELAPSED REST" into its fields.
+// The rest of the line is returned without its line ending, and may be empty.
+// This layout is shared by script's advanced format and sudo's I/O log timing files.
+func SplitTimingLine(line string) (code string, elapsed float64, rest string, err error) {
+	fields := strings.SplitN(strings.TrimRight(line, "\r\n"), " ", 3)
+	if len(fields) < 2 || fields[0] == "" {
+		return "", 0, "", fmt.Errorf("invalid timing line %q", line)
+	}
+
+	elapsed, err = strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("invalid elapsed time in timing line %q", line)
+	}
+
+	if len(fields) == 3 {
+		rest = fields[2]
+	}
+	return fields[0], elapsed, rest, nil
+}
+
+func parseAdvancedTiming(s string) (e Event, dataLen int, err error) {
+	code, elapsed, rest, err := SplitTimingLine(s)
+	if err != nil {
+		return e, 0, err
+	}
+	if len(code) != 1 || rest == "" {
+		return e, 0, fmt.Errorf("invalid timing line %q", s)
+	}
+
+	e.Code = rune(code[0])
+	e.ElapsedSeconds = elapsed
+
+	if !e.HasData() {
+		e.Name, e.Data, _ = strings.Cut(rest, " ")
+		return e, 0, nil
+	}
+
+	dataLen, err = strconv.Atoi(rest)
+	if err != nil {
+		return e, 0, fmt.Errorf("invalid length in timing line %q", s)
+	}
+
+	return e, dataLen, nil
 }
 
 func parseClassicTiming(s string) (elapsed float64, dataLen int, err error) {
@@ -69,6 +193,15 @@ func parseClassicTiming(s string) (elapsed float64, dataLen int, err error) {
 
 // Write event in advanced timing format
 func (e *Event) WriteAdvanced(typescript, timingfile io.Writer) error {
+	if !e.HasData() {
+		if e.Data == "" {
+			_, err := fmt.Fprintf(timingfile, "%c %f %s\n", e.Code, e.ElapsedSeconds, e.Name)
+			return err
+		}
+		_, err := fmt.Fprintf(timingfile, "%c %f %s %s\n", e.Code, e.ElapsedSeconds, e.Name, e.Data)
+		return err
+	}
+
 	if _, err := fmt.Fprintf(timingfile, "%c %f %d\n", e.Code, e.ElapsedSeconds, len(e.Data)); err != nil {
 		return err
 	}
@@ -79,3 +212,25 @@ func (e *Event) WriteAdvanced(typescript, timingfile io.Writer) error {
 
 	return nil
 }
+
+// Write event in classic timing format.
+// Only output events can be written in the classic format.
+func (e *Event) WriteClassic(typescript, timingfile io.Writer) error {
+	switch e.Code {
+	case OutputCode:
+	case InputCode:
+		return ErrClassicInput
+	default:
+		return fmt.Errorf("classic timing format can not record %c events", e.Code)
+	}
+
+	if _, err := fmt.Fprintf(timingfile, "%f %d\n", e.ElapsedSeconds, len(e.Data)); err != nil {
+		return err
+	}
+
+	if _, err := typescript.Write([]byte(e.Data)); err != nil {
+		return err
+	}
+
+	return nil
+}
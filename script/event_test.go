@@ -7,7 +7,10 @@ package script
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"io"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -23,6 +26,7 @@ func TestRoundtrip(t *testing.T) {
 			ElapsedSeconds: 1.23,
 			Code:           'I',
 		},
+		NewWinchEvent(0.5, 30, 100),
 	}
 
 	var typescript, timing bytes.Buffer
@@ -45,3 +49,25 @@ func TestRoundtrip(t *testing.T) {
 		t.Errorf("Decoded events not the same as original:\nExpected: %#v\nActual:   %#v\n", events, eventsOut)
 	}
 }
+
+func TestWindowSize(t *testing.T) {
+	rows, cols, err := NewWinchEvent(0, 30, 100).WindowSize()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rows != 30 || cols != 100 {
+		t.Errorf("Wrong window size (expected 30x100, got %dx%d)", rows, cols)
+	}
+
+	if _, _, err := (Event{Code: SignalCode, Name: SigWinch, Data: "ROWS=x"}).WindowSize(); err == nil {
+		t.Errorf("Expected error for invalid window size")
+	}
+}
+
+func TestTruncatedTypescript(t *testing.T) {
+	var event Event
+	err := event.Take(strings.NewReader("hel"), bufio.NewReader(strings.NewReader("O 0.500000 5\n")))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
@@ -27,6 +27,9 @@ type Header struct {
 
 var _ fmt.Stringer = Header{}
 
+// String formats h as the header line of a typescript.
+// Without a terminal type, the line says the recording wasn't made on a terminal
+// and leaves out the TTY and the terminal size, as util-linux script does.
 func (h Header) String() string {
 	var builder strings.Builder
 	fmt.Fprintf(&builder, `Script started on %s [`, h.Start.Format(startFormat))
@@ -37,31 +40,23 @@ func (h Header) String() string {
 		spacer = " "
 	}
 
-	isTerminal := false
-
-	if h.Term != "" {
-		fmt.Fprintf(&builder, `%sTERM="%s"`, spacer, h.Term)
-		spacer = " "
-		isTerminal = true
+	if h.Term == "" {
+		fmt.Fprintf(&builder, `%s<not executed on terminal>]`, spacer)
+		return builder.String()
 	}
 
+	fmt.Fprintf(&builder, `%sTERM="%s"`, spacer, h.Term)
+
 	if h.Tty != "" {
-		fmt.Fprintf(&builder, `%sTTY="%s"`, spacer, h.Tty)
-		spacer = " "
+		fmt.Fprintf(&builder, ` TTY="%s"`, h.Tty)
 	}
 
 	if h.Columns != 0 {
-		fmt.Fprintf(&builder, `%sCOLUMNS="%d"`, spacer, h.Columns)
-		spacer = " "
+		fmt.Fprintf(&builder, ` COLUMNS="%d"`, h.Columns)
 	}
 
 	if h.Lines != 0 {
-		fmt.Fprintf(&builder, `%sLINES="%d"`, spacer, h.Lines)
-		spacer = " "
-	}
-
-	if !isTerminal {
-		fmt.Fprintf(&builder, `%s<not executed on terminal>`, spacer)
+		fmt.Fprintf(&builder, ` LINES="%d"`, h.Lines)
 	}
 
 	fmt.Fprint(&builder, "]")
@@ -72,18 +67,26 @@ func (h Header) String() string {
 // Currently the regex assumes there will be no quotes in fields other than <command>.
 // This is done to make quotes in the command match reliably, with the tradeoff that
 // quotes in <term> or <tty> will cause those fields to be interpreted as part of the commmand.
-var headerRegex = regexp.MustCompile("^Script started on (?P<date>" + dateRegex + `) \[` +
-	`(?:COMMAND="(?P<command>.*?)" )?` +
-	`(?:(?:` + // terminal exists
-	`(?:TERM="(?P<term>[^"]*?)" )?` +
-	`(?:TTY="(?P<tty>[^"]*?)" )?` +
-	`COLUMNS="(?P<columns>[0-9]+)" LINES="(?P<lines>[0-9]+)"` +
-	`)|(?:` + // not a terminal
-	`<not executed on terminal>` +
-	`))` +
-	"")
+var headerRegex = newHeaderRegex(`Script started on `)
+
+func newHeaderRegex(prefix string) *regexp.Regexp {
+	return regexp.MustCompile("^" + prefix + "(?P<date>" + dateRegex + `) \[` +
+		`(?:COMMAND="(?P<command>.*?)" )?` +
+		`(?:(?:` + // terminal exists
+		`(?:TERM="(?P<term>[^"]*?)" )?` +
+		`(?:TTY="(?P<tty>[^"]*?)" )?` +
+		`COLUMNS="(?P<columns>[0-9]+)" LINES="(?P<lines>[0-9]+)"` +
+		`)|(?:` + // not a terminal
+		`<not executed on terminal>` +
+		`))` +
+		"")
+}
 
 func ParseHeader(header string) (result Header, err error) {
+	return parseHeaderRegex(headerRegex, header)
+}
+
+func parseHeaderRegex(headerRegex *regexp.Regexp, header string) (result Header, err error) {
 	match := headerRegex.FindSubmatch([]byte(header))
 	if match == nil {
 		return result, fmt.Errorf("improper header structure")
@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"regexp"
 	"testing"
+	"time"
 )
 
 func TestDateRegex(t *testing.T) {
@@ -63,3 +64,16 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestStringNotTerminal(t *testing.T) {
+	// The size filled in for a recording made without a terminal isn't written
+	h := Header{Start: time.Date(2025, 4, 1, 12, 34, 56, 0, time.UTC), Tty: "/dev/pts/2", Columns: 80, Lines: 24}
+	expected := `Script started on 2025-04-01 12:34:56+00:00 [<not executed on terminal>]`
+	if h.String() != expected {
+		t.Errorf("Wrong header:\nExpected: %s\nActual:   %s", expected, h.String())
+	}
+
+	if _, err := ParseHeader(h.String()); err != nil {
+		t.Errorf("Error parsing header: %v", err)
+	}
+}
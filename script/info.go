@@ -0,0 +1,124 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package script
+
+import (
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Info holds the "H" entries of an advanced format timingfile, keyed by name.
+type Info map[string]string
+
+// Names of info entries written by util-linux script
+const (
+	InfoStartTime = "START_TIME"
+	InfoTerm      = "TERM"
+	InfoTty       = "TTY"
+	InfoColumns   = "COLUMNS"
+	InfoLines     = "LINES"
+	InfoShell     = "SHELL"
+	InfoCommand   = "COMMAND"
+	InfoTimingLog = "TIMING_LOG"
+	InfoOutputLog = "OUTPUT_LOG"
+	InfoInputLog  = "INPUT_LOG"
+	InfoDuration  = "DURATION"
+	InfoExitCode  = "EXIT_CODE"
+)
+
+// Order in which util-linux script writes info entries
+var infoOrder = []string{
+	InfoStartTime,
+	InfoTerm,
+	InfoTty,
+	InfoColumns,
+	InfoLines,
+	InfoShell,
+	InfoCommand,
+	InfoTimingLog,
+	InfoOutputLog,
+	InfoInputLog,
+	InfoDuration,
+	InfoExitCode,
+}
+
+// HeaderInfo returns the info entries describing h.
+func HeaderInfo(h Header) Info {
+	info := Info{}
+	if !h.Start.IsZero() {
+		info[InfoStartTime] = h.Start.Format(startFormat)
+	}
+	if h.Term != "" {
+		info[InfoTerm] = h.Term
+	}
+	if h.Tty != "" {
+		info[InfoTty] = h.Tty
+	}
+	if h.Columns != 0 {
+		info[InfoColumns] = strconv.Itoa(h.Columns)
+	}
+	if h.Lines != 0 {
+		info[InfoLines] = strconv.Itoa(h.Lines)
+	}
+	if h.Command != "" {
+		info[InfoCommand] = h.Command
+	}
+	return info
+}
+
+// ApplyTo sets the fields of h that are present in i.
+// Malformed START_TIME, COLUMNS and LINES entries are ignored, keeping the fields of h.
+func (i Info) ApplyTo(h *Header) {
+	if start, err := time.Parse(startFormat, i[InfoStartTime]); err == nil {
+		h.Start = start
+	}
+
+	if value, ok := i[InfoTerm]; ok {
+		h.Term = value
+	}
+
+	if value, ok := i[InfoTty]; ok {
+		h.Tty = value
+	}
+
+	if columns, err := strconv.Atoi(i[InfoColumns]); err == nil {
+		h.Columns = columns
+	}
+
+	if lines, err := strconv.Atoi(i[InfoLines]); err == nil {
+		h.Lines = lines
+	}
+
+	if value, ok := i[InfoCommand]; ok {
+		h.Command = value
+	}
+}
+
+// Events returns the entries as info events, in the order util-linux script writes them.
+// Unknown entries follow in alphabetical order.
+func (i Info) Events() []Event {
+	var names []string
+	for _, name := range infoOrder {
+		if _, ok := i[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	var unknown []string
+	for name := range i {
+		if !slices.Contains(infoOrder, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	slices.Sort(unknown)
+	names = append(names, unknown...)
+
+	events := make([]Event, len(names))
+	for j, name := range names {
+		events[j] = Event{Code: InfoCode, Name: name, Data: i[name]}
+	}
+	return events
+}
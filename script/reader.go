@@ -0,0 +1,189 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package script
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Reader reads the events of a typescript and its timingfile.
+//
+// Info entries are collected into Info as they are read.
+// The entries at the start of the timingfile are read by NewReader,
+// so they are available before the first call to Next.
+//
+// The data of each event is returned as script wrote it, which may end
+// within a multibyte character that script split between two entries.
+type Reader struct {
+	output     *stream
+	input      *stream // same as output for a single typescript
+	timingfile *bufio.Reader
+	info       Info
+	pending    []Event // events read ahead of Next
+}
+
+// A typescript of a recording
+type stream struct {
+	r       *bufio.Reader
+	header  Header
+	trailer *Trailer
+}
+
+// ReaderOptions controls how a Reader parses a recording.
+type ReaderOptions struct {
+	Header HeaderParser // parses the header lines of the typescripts
+}
+
+// NewReader reads the header line of typescript and the leading info entries of timingfile.
+func NewReader(typescript, timingfile io.Reader) (*Reader, error) {
+	return NewMultiReader(typescript, nil, timingfile)
+}
+
+// NewMultiReader is like NewReader for recordings with separate output
+// and input typescripts (script --log-out/--log-in).
+// If input is nil, input events are read from output like with --log-io.
+// Output may be nil if only input was logged.
+func NewMultiReader(output, input, timingfile io.Reader) (*Reader, error) {
+	return NewMultiReaderOptions(output, input, timingfile, ReaderOptions{})
+}
+
+// NewMultiReaderOptions is like NewMultiReader with options other than the defaults.
+func NewMultiReaderOptions(output, input, timingfile io.Reader, opts ReaderOptions) (*Reader, error) {
+	if output == nil && input == nil {
+		return nil, fmt.Errorf("no typescript")
+	}
+
+	r := &Reader{
+		timingfile: bufio.NewReader(timingfile),
+		info:       Info{},
+	}
+
+	var err error
+	if output != nil {
+		if r.output, err = newStream(output, opts.Header); err != nil {
+			return nil, err
+		}
+	}
+
+	r.input = r.output
+	if input != nil {
+		if r.input, err = newStream(input, opts.Header); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		next, err := r.timingfile.Peek(1)
+		if err != nil || next[0] != InfoCode {
+			break
+		}
+
+		event, err := r.take()
+		if err != nil {
+			return nil, err
+		}
+		r.info[event.Name] = event.Data
+		r.pending = append(r.pending, event)
+	}
+
+	return r, nil
+}
+
+func newStream(typescript io.Reader, parser HeaderParser) (*stream, error) {
+	s := &stream{r: bufio.NewReader(typescript)}
+
+	headerLine, err := s.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	s.header, err = parser.Parse(headerLine)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Read the data after the last event, which should be the trailer
+func (s *stream) readTrailer() {
+	rest, err := io.ReadAll(s.r)
+	if err != nil {
+		return
+	}
+
+	if trailer, err := ParseTrailer(string(rest)); err == nil {
+		s.trailer = &trailer
+	}
+}
+
+// The typescript holding the metadata of the recording
+func (r *Reader) main() *stream {
+	if r.output != nil {
+		return r.output
+	}
+	return r.input
+}
+
+// Header returns the header line of the typescript.
+// With separate typescripts, the header of the output typescript is preferred.
+func (r *Reader) Header() Header {
+	return r.main().header
+}
+
+// Info returns the info entries read so far.
+func (r *Reader) Info() Info {
+	return r.info
+}
+
+// Trailer returns the "Script done on" line of the typescript.
+// It is only available once Next has returned io.EOF.
+func (r *Reader) Trailer() (trailer Trailer, ok bool) {
+	if r.main().trailer == nil {
+		return Trailer{}, false
+	}
+	return *r.main().trailer, true
+}
+
+// Next returns the next event of the timingfile, including info events.
+// It returns io.EOF when there are no more events, and an error wrapping
+// io.ErrUnexpectedEOF if a typescript is cut off before the end of the timingfile.
+func (r *Reader) Next() (Event, error) {
+	if len(r.pending) > 0 {
+		event := r.pending[0]
+		r.pending = r.pending[1:]
+		return event, nil
+	}
+
+	event, err := r.take()
+	if err != nil {
+		if err == io.EOF {
+			r.main().readTrailer()
+		}
+		return event, err
+	}
+
+	if event.Code == InfoCode {
+		r.info[event.Name] = event.Data
+	}
+
+	return event, nil
+}
+
+func (r *Reader) take() (Event, error) {
+	var output, input io.Reader
+	if r.output != nil {
+		output = r.output.r
+	}
+	if r.input != nil {
+		input = r.input.r
+	}
+
+	var event Event
+	err := event.TakeMulti(output, input, r.timingfile)
+	return event, err
+}
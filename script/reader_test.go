@@ -0,0 +1,64 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package script
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReaderInfo(t *testing.T) {
+	typescript := `Script started on 2025-04-01 12:34:56-07:00 [TERM="xterm" TTY="/dev/pts/2" COLUMNS="80" LINES="24"]` + "\n" +
+		"hello"
+	timingfile := "H 0.000000 START_TIME 2025-04-01 12:34:56-07:00\n" +
+		"H 0.000000 COLUMNS 100\n" +
+		"H 0.000000 SHELL /bin/bash\n" +
+		"O 0.500000 5\n" +
+		"H 0.000000 EXIT_CODE 0\n"
+
+	reader, err := NewReader(strings.NewReader(typescript), strings.NewReader(timingfile))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedInfo := Info{
+		InfoStartTime: "2025-04-01 12:34:56-07:00",
+		InfoColumns:   "100",
+		InfoShell:     "/bin/bash",
+	}
+	if !reflect.DeepEqual(reader.Info(), expectedInfo) {
+		t.Fatalf("Wrong leading info:\nExpected: %#v\nActual:   %#v", expectedInfo, reader.Info())
+	}
+
+	header := reader.Header()
+	reader.Info().ApplyTo(&header)
+	if header.Columns != 100 || header.Lines != 24 {
+		t.Errorf("Info not applied to header: %#v", header)
+	}
+
+	var data strings.Builder
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if event.HasData() {
+			data.WriteString(event.Data)
+		}
+	}
+
+	if data.String() != "hello" {
+		t.Errorf("Wrong data %q", data.String())
+	}
+
+	if reader.Info()[InfoExitCode] != "0" {
+		t.Errorf("Trailing info entry not collected")
+	}
+}
@@ -0,0 +1,63 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package script
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Trailer is the "Script done on" line at the end of a typescript.
+type Trailer struct {
+	Done     time.Time
+	ExitCode int
+	Message  string // reason shown instead of the exit code, ex. "max output size exceeded"
+}
+
+var _ fmt.Stringer = Trailer{}
+
+func (t Trailer) String() string {
+	if t.Message != "" {
+		return fmt.Sprintf(`Script done on %s [<%s>]`, t.Done.Format(startFormat), t.Message)
+	}
+	return fmt.Sprintf(`Script done on %s [COMMAND_EXIT_CODE="%d"]`, t.Done.Format(startFormat), t.ExitCode)
+}
+
+var trailerRegex = regexp.MustCompile("^Script done on (?P<date>" + dateRegex + `) \[` +
+	`(?:COMMAND_EXIT_CODE="(?P<code>-?[0-9]+)"|<(?P<message>.*)>)\]$`)
+
+// ParseTrailer parses a "Script done on" line.
+// Surrounding whitespace, such as the newline script writes before the trailer, is ignored.
+func ParseTrailer(trailer string) (result Trailer, err error) {
+	match := trailerRegex.FindStringSubmatch(strings.TrimSpace(trailer))
+	if match == nil {
+		return result, fmt.Errorf("improper trailer structure")
+	}
+
+	for i, subexp := range trailerRegex.SubexpNames() {
+		if match[i] == "" {
+			continue
+		}
+
+		switch subexp {
+		case "date":
+			result.Done, err = time.ParseInLocation(startFormat, match[i], time.UTC)
+			if err != nil {
+				return result, err
+			}
+		case "code":
+			result.ExitCode, err = strconv.Atoi(match[i])
+			if err != nil {
+				return result, err
+			}
+		case "message":
+			result.Message = match[i]
+		}
+	}
+	return result, nil
+}
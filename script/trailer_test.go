@@ -0,0 +1,50 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package script
+
+import (
+	"testing"
+)
+
+func TestParseTrailer(t *testing.T) {
+	testCases := []struct {
+		str      string
+		expected Trailer
+	}{
+		{
+			str:      "\nScript done on 2025-04-01 12:35:00-07:00 [COMMAND_EXIT_CODE=\"130\"]\n",
+			expected: Trailer{ExitCode: 130},
+		},
+		{
+			str:      `Script done on 2025-04-01 12:35:00-07:00 [<max output size exceeded>]`,
+			expected: Trailer{Message: "max output size exceeded"},
+		},
+	}
+
+	for i, testCase := range testCases {
+		trailer, err := ParseTrailer(testCase.str)
+		if err != nil {
+			t.Fatalf("Test %d: Unexpected error: %v", i, err)
+		}
+
+		if _, zone := trailer.Done.Zone(); zone != -7*60*60 {
+			t.Errorf("Test %d: Wrong timezone offset %d", i, zone)
+		}
+
+		testCase.expected.Done = trailer.Done
+		if trailer != testCase.expected {
+			t.Errorf("Test %d:\nExpected: %#v\nActual:   %#v", i, testCase.expected, trailer)
+		}
+
+		reparsed, err := ParseTrailer(trailer.String())
+		if err != nil || reparsed != trailer {
+			t.Errorf("Test %d: String() does not round trip: %q", i, trailer.String())
+		}
+	}
+
+	if _, err := ParseTrailer("Script done on yesterday"); err == nil {
+		t.Errorf("Expected error for malformed trailer")
+	}
+}
@@ -0,0 +1,101 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package script
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/wk-y/asciicast2script/internal/timing"
+)
+
+// ErrClassicInput is returned when writing an input event in the classic timing format.
+var ErrClassicInput = errors.New("classic timing format can not record input")
+
+// Writer writes events to a typescript and a timingfile.
+type Writer struct {
+	// Write the classic timing format instead of the advanced format.
+	// Signal and info events are dropped, with their time added to the next event.
+	// Input events can not be written.
+	Classic bool
+
+	output     io.Writer
+	input      io.Writer // same as output for a single typescript
+	timingfile io.Writer
+	carry      timing.Micros // time of dropped events
+}
+
+// NewWriter creates a Writer for a single typescript.
+func NewWriter(typescript, timingfile io.Writer) *Writer {
+	return NewMultiWriter(typescript, nil, timingfile)
+}
+
+// NewMultiWriter creates a Writer with separate output and input
+// typescripts (script --log-out/--log-in).
+// If input is nil, input events are written to output like with --log-io.
+func NewMultiWriter(output, input, timingfile io.Writer) *Writer {
+	if input == nil {
+		input = output
+	}
+
+	return &Writer{
+		output:     output,
+		input:      input,
+		timingfile: timingfile,
+	}
+}
+
+// Write each typescript once
+func (w *Writer) typescripts() []io.Writer {
+	if w.input == w.output {
+		return []io.Writer{w.output}
+	}
+	return []io.Writer{w.output, w.input}
+}
+
+// WriteHeader writes the header line to the typescripts.
+func (w *Writer) WriteHeader(h Header) error {
+	for _, typescript := range w.typescripts() {
+		if _, err := fmt.Fprintln(typescript, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write writes an event, with its data going to the typescript matching its code.
+func (w *Writer) Write(e Event) error {
+	typescript := w.output
+	if e.Code == InputCode {
+		typescript = w.input
+	}
+
+	if !w.Classic {
+		return e.WriteAdvanced(typescript, w.timingfile)
+	}
+
+	switch e.Code {
+	case OutputCode:
+		e.ElapsedSeconds = (timing.FromSeconds(e.ElapsedSeconds) + w.carry).Seconds()
+		w.carry = 0
+		return e.WriteClassic(typescript, w.timingfile)
+	case InputCode:
+		return ErrClassicInput
+	default:
+		w.carry += timing.FromSeconds(e.ElapsedSeconds)
+		return nil
+	}
+}
+
+// WriteTrailer writes the trailer to the typescripts.
+func (w *Writer) WriteTrailer(t Trailer) error {
+	for _, typescript := range w.typescripts() {
+		if _, err := fmt.Fprintf(typescript, "\n%s\n", t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
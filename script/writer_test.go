@@ -0,0 +1,104 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package script
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMultiRoundtrip(t *testing.T) {
+	header := Header{
+		Start:   time.Date(2025, 4, 1, 12, 34, 56, 0, time.UTC),
+		Term:    "xterm",
+		Columns: 80,
+		Lines:   24,
+	}
+
+	events := []Event{
+		{Data: "$ ", ElapsedSeconds: 0.5, Code: OutputCode},
+		{Data: "ls\r", ElapsedSeconds: 1, Code: InputCode},
+		NewWinchEvent(0.25, 30, 100),
+		{Data: "file\r\n", ElapsedSeconds: 0.125, Code: OutputCode},
+	}
+
+	var output, input, timing bytes.Buffer
+	writer := NewMultiWriter(&output, &input, &timing)
+	if err := writer.WriteHeader(header); err != nil {
+		t.Fatalf("Error writing header: %v", err)
+	}
+	for i, event := range events {
+		if err := writer.Write(event); err != nil {
+			t.Fatalf("Error writing event %d: %v", i, err)
+		}
+	}
+	if err := writer.WriteTrailer(Trailer{Done: header.Start.Add(2 * time.Second), ExitCode: 1}); err != nil {
+		t.Fatalf("Error writing trailer: %v", err)
+	}
+
+	if strings.Contains(output.String(), "ls") || !strings.Contains(input.String(), "ls") {
+		t.Fatalf("Input not written to the input typescript:\nOutput: %q\nInput:  %q", output.String(), input.String())
+	}
+
+	reader, err := NewMultiReader(&output, &input, &timing)
+	if err != nil {
+		t.Fatalf("Error reading headers: %v", err)
+	}
+
+	var eventsOut []Event
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error reading event: %v", err)
+		}
+		eventsOut = append(eventsOut, event)
+	}
+
+	if !reflect.DeepEqual(events, eventsOut) {
+		t.Errorf("Decoded events not the same as original:\nExpected: %#v\nActual:   %#v", events, eventsOut)
+	}
+
+	if trailer, ok := reader.Trailer(); !ok || trailer.ExitCode != 1 {
+		t.Errorf("Trailer not read: %#v", trailer)
+	}
+}
+
+func TestClassic(t *testing.T) {
+	var typescript, timing bytes.Buffer
+	writer := NewWriter(&typescript, &timing)
+	writer.Classic = true
+
+	events := []Event{
+		{Code: InfoCode, Name: InfoTerm, Data: "xterm"},
+		{Data: "hello", ElapsedSeconds: 0.5, Code: OutputCode},
+		NewWinchEvent(0.25, 30, 100),
+		{Data: "world", ElapsedSeconds: 0.25, Code: OutputCode},
+	}
+	for i, event := range events {
+		if err := writer.Write(event); err != nil {
+			t.Fatalf("Error writing event %d: %v", i, err)
+		}
+	}
+
+	expected := "0.500000 5\n0.500000 5\n"
+	if timing.String() != expected {
+		t.Errorf("Wrong timingfile:\nExpected: %q\nActual:   %q", expected, timing.String())
+	}
+
+	if err := writer.Write(Event{Data: "x", Code: InputCode}); err != ErrClassicInput {
+		t.Errorf("Expected ErrClassicInput, got %v", err)
+	}
+	event := Event{Data: "x", Code: InputCode}
+	if err := event.WriteClassic(&typescript, &timing); err != ErrClassicInput {
+		t.Errorf("Expected ErrClassicInput from WriteClassic, got %v", err)
+	}
+}
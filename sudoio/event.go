@@ -0,0 +1,134 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package sudoio reads and writes sudo I/O logs, as replayed by sudoreplay.
+//
+// An I/O log is a directory holding a "log" file with the session metadata,
+// a "timing" file and one file per recorded stream ("ttyout", "ttyin", "stdout", ...).
+// Files compressed by sudo's compress_io option are decompressed transparently.
+package sudoio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wk-y/asciicast2script/script"
+)
+
+// Stream identifies the type of a timing entry.
+type Stream int
+
+// Timing entry types
+const (
+	Stdin        Stream = 0
+	Stdout       Stream = 1
+	Stderr       Stream = 2
+	TtyIn        Stream = 3
+	TtyOut       Stream = 4
+	WindowSize   Stream = 5
+	TtyOutLegacy Stream = 6 // ttyout of sudo 1.8.7
+	Suspend      Stream = 7
+)
+
+// File returns the name of the file holding the data of s.
+func (s Stream) File() string {
+	switch s {
+	case Stdin:
+		return "stdin"
+	case Stdout:
+		return "stdout"
+	case Stderr:
+		return "stderr"
+	case TtyIn:
+		return "ttyin"
+	case TtyOut, TtyOutLegacy:
+		return "ttyout"
+	default:
+		return ""
+	}
+}
+
+// HasData reports whether entries of s carry data from a stream file.
+func (s Stream) HasData() bool {
+	return s.File() != ""
+}
+
+// IsInput reports whether s holds input to the command.
+func (s Stream) IsInput() bool {
+	return s == Stdin || s == TtyIn
+}
+
+// Event is an entry of the timing file.
+//
+// Data holds the stream data of I/O entries.
+// Window size entries set Rows and Cols, suspend entries set Signal.
+type Event struct {
+	Data           string
+	ElapsedSeconds float64
+	Stream         Stream
+	Rows, Cols     int
+	Signal         string
+}
+
+// parseTiming parses a timing line, returning the length of the event's data.
+func parseTiming(line string) (e Event, dataLen int, err error) {
+	code, elapsed, rest, err := script.SplitTimingLine(line)
+	if err != nil {
+		return e, 0, err
+	}
+
+	stream, err := strconv.Atoi(code)
+	if err != nil {
+		return e, 0, fmt.Errorf("invalid stream in timing line %q", line)
+	}
+
+	e.Stream = Stream(stream)
+	e.ElapsedSeconds = elapsed
+	fields := strings.Fields(rest)
+
+	switch {
+	case e.Stream == WindowSize:
+		if len(fields) != 2 {
+			return e, 0, fmt.Errorf("invalid window size in timing line %q", line)
+		}
+		e.Rows, err = strconv.Atoi(fields[0])
+		if err != nil {
+			return e, 0, fmt.Errorf("invalid window size in timing line %q", line)
+		}
+		e.Cols, err = strconv.Atoi(fields[1])
+		if err != nil {
+			return e, 0, fmt.Errorf("invalid window size in timing line %q", line)
+		}
+	case e.Stream == Suspend:
+		if len(fields) != 1 {
+			return e, 0, fmt.Errorf("invalid signal in timing line %q", line)
+		}
+		e.Signal = fields[0]
+	case e.Stream.HasData():
+		if len(fields) != 1 {
+			return e, 0, fmt.Errorf("invalid length in timing line %q", line)
+		}
+		dataLen, err = strconv.Atoi(fields[0])
+		if err != nil || dataLen < 0 {
+			return e, 0, fmt.Errorf("invalid length in timing line %q", line)
+		}
+	default:
+		return e, 0, fmt.Errorf("unknown stream %d in timing line %q", stream, line)
+	}
+
+	return e, dataLen, nil
+}
+
+// timingLine formats the timing entry of e.
+func (e Event) timingLine() string {
+	switch e.Stream {
+	case WindowSize:
+		return fmt.Sprintf("%d %f %d %d\n", e.Stream, e.ElapsedSeconds, e.Rows, e.Cols)
+	case Suspend:
+		return fmt.Sprintf("%d %f %s\n", e.Stream, e.ElapsedSeconds, e.Signal)
+	default:
+		return fmt.Sprintf("%d %f %d\n", e.Stream, e.ElapsedSeconds, len(e.Data))
+	}
+}
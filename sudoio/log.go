@@ -0,0 +1,85 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package sudoio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Log is the session metadata stored in the "log" file of an I/O log.
+type Log struct {
+	Start      time.Time
+	User       string
+	RunasUser  string
+	RunasGroup string
+	Tty        string
+	Lines      int
+	Columns    int
+	Cwd        string
+	Command    string
+}
+
+var _ fmt.Stringer = Log{}
+
+// String formats the log file contents.
+func (l Log) String() string {
+	return fmt.Sprintf("%d:%s:%s:%s:%s:%d:%d\n%s\n%s\n",
+		l.Start.Unix(), l.User, l.RunasUser, l.RunasGroup, l.Tty, l.Lines, l.Columns, l.Cwd, l.Command)
+}
+
+// ParseLog parses the contents of a "log" file.
+// Logs of older sudo versions without the terminal size are accepted.
+func ParseLog(r io.Reader) (result Log, err error) {
+	scanner := bufio.NewScanner(r)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+
+	if len(lines) == 0 {
+		return result, fmt.Errorf("empty log file")
+	}
+
+	fields := strings.Split(lines[0], ":")
+	if len(fields) != 5 && len(fields) != 7 {
+		return result, fmt.Errorf("improper log structure")
+	}
+
+	seconds, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return result, fmt.Errorf("invalid start time in log: %w", err)
+	}
+	result.Start = time.Unix(seconds, 0)
+	result.User = fields[1]
+	result.RunasUser = fields[2]
+	result.RunasGroup = fields[3]
+	result.Tty = fields[4]
+
+	if len(fields) == 7 {
+		if result.Lines, err = strconv.Atoi(fields[5]); err != nil {
+			return result, fmt.Errorf("invalid lines in log: %w", err)
+		}
+		if result.Columns, err = strconv.Atoi(fields[6]); err != nil {
+			return result, fmt.Errorf("invalid columns in log: %w", err)
+		}
+	}
+
+	if len(lines) > 1 {
+		result.Cwd = lines[1]
+	}
+	if len(lines) > 2 {
+		result.Command = lines[2]
+	}
+
+	return result, nil
+}
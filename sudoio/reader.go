@@ -0,0 +1,129 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package sudoio
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// Reader reads the events of an I/O log.
+type Reader struct {
+	fsys    fs.FS
+	log     Log
+	timing  *bufio.Reader
+	files   map[string]io.Reader
+	closers []io.Closer
+}
+
+// Open reads the log file of the I/O log in fsys and opens its timing file.
+// Use os.DirFS to open an I/O log directory.
+func Open(fsys fs.FS) (*Reader, error) {
+	r := &Reader{
+		fsys:  fsys,
+		files: map[string]io.Reader{},
+	}
+
+	logFile, err := r.open("log")
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	r.log, err = ParseLog(logFile)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	timing, err := r.open("timing")
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	r.timing = bufio.NewReader(timing)
+
+	return r, nil
+}
+
+// Open a file of the I/O log, decompressing it if needed
+func (r *Reader) open(name string) (io.Reader, error) {
+	file, err := r.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	r.closers = append(r.closers, file)
+
+	buffered := bufio.NewReader(file)
+	if magic, err := buffered.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		decompressed, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		return decompressed, nil
+	}
+
+	return buffered, nil
+}
+
+// Log returns the session metadata.
+func (r *Reader) Log() Log {
+	return r.log
+}
+
+// Next returns the next event of the timing file.
+// It returns io.EOF when there are no more events, and an error wrapping
+// io.ErrUnexpectedEOF if a stream file ends before the data of the event,
+// as it does when sudo is killed while logging.
+func (r *Reader) Next() (Event, error) {
+	line, err := r.timing.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return Event{}, err
+	}
+
+	event, dataLen, err := parseTiming(line)
+	if err != nil {
+		return event, err
+	}
+
+	if !event.Stream.HasData() {
+		return event, nil
+	}
+
+	name := event.Stream.File()
+	file, ok := r.files[name]
+	if !ok {
+		file, err = r.open(name)
+		if err != nil {
+			return event, fmt.Errorf("opening %s stream: %w", name, err)
+		}
+		r.files[name] = file
+	}
+
+	buf := make([]byte, dataLen)
+	if _, err := io.ReadFull(file, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return event, fmt.Errorf("%s stream truncated: %w", name, io.ErrUnexpectedEOF)
+		}
+		return event, err
+	}
+	event.Data = string(buf)
+
+	return event, nil
+}
+
+// Close closes the files of the I/O log.
+func (r *Reader) Close() error {
+	var errs []error
+	for _, closer := range r.closers {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,156 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package sudoio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func gzipped(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"log":    {Data: []byte("1743536096:alice:root::/dev/pts/1:24:80\n/home/alice\n/usr/bin/vi /etc/hosts\n")},
+		"timing": {Data: gzipped(t, "4 0.500000 5\n3 0.250000 2\n5 0.100000 30 100\n7 1.000000 TSTP\n4 0.125000 6\n")},
+		"ttyout": {Data: gzipped(t, "hello world")},
+		"ttyin":  {Data: []byte(":q")},
+	}
+
+	reader, err := Open(fsys)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer reader.Close()
+
+	expectedLog := Log{
+		Start:     time.Unix(1743536096, 0),
+		User:      "alice",
+		RunasUser: "root",
+		Tty:       "/dev/pts/1",
+		Lines:     24,
+		Columns:   80,
+		Cwd:       "/home/alice",
+		Command:   "/usr/bin/vi /etc/hosts",
+	}
+	if !reflect.DeepEqual(reader.Log(), expectedLog) {
+		t.Errorf("Wrong log:\nExpected: %#v\nActual:   %#v", expectedLog, reader.Log())
+	}
+
+	expected := []Event{
+		{Data: "hello", ElapsedSeconds: 0.5, Stream: TtyOut},
+		{Data: ":q", ElapsedSeconds: 0.25, Stream: TtyIn},
+		{ElapsedSeconds: 0.1, Stream: WindowSize, Rows: 30, Cols: 100},
+		{ElapsedSeconds: 1, Stream: Suspend, Signal: "TSTP"},
+		{Data: " world", ElapsedSeconds: 0.125, Stream: TtyOut},
+	}
+
+	var events []Event
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		events = append(events, event)
+	}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Wrong events:\nExpected: %#v\nActual:   %#v", expected, events)
+	}
+}
+
+func TestReaderTruncated(t *testing.T) {
+	fsys := fstest.MapFS{
+		"log":    {Data: []byte("1743536096:alice:root::/dev/pts/1\n/\n/bin/sh\n")},
+		"timing": {Data: []byte("4 0.500000 5\n4 0.500000 6\n")},
+		"ttyout": {Data: []byte("hello wo")},
+	}
+
+	reader, err := Open(fsys)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer reader.Close()
+
+	if _, err := reader.Next(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := reader.Next(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestWriterRoundtrip(t *testing.T) {
+	dir := t.TempDir()
+	log := Log{Start: time.Unix(1743536096, 0), User: "alice", RunasUser: "root", Tty: "/dev/pts/1", Lines: 24, Columns: 80, Cwd: "/", Command: "/bin/sh"}
+	events := []Event{
+		{Data: "$ ", ElapsedSeconds: 0.5, Stream: TtyOut},
+		{Data: "exit\r", ElapsedSeconds: 1, Stream: TtyIn},
+		{ElapsedSeconds: 0.25, Stream: WindowSize, Rows: 30, Cols: 100},
+	}
+
+	// A stream file of an earlier log in the directory
+	if err := os.WriteFile(filepath.Join(dir, "stdout"), []byte("stale"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := Create(dir, log)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i, event := range events {
+		if err := writer.Write(event); err != nil {
+			t.Fatalf("Error writing event %d: %v", i, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	reader, err := Open(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer reader.Close()
+
+	if !reflect.DeepEqual(reader.Log(), log) {
+		t.Errorf("Wrong log:\nExpected: %#v\nActual:   %#v", log, reader.Log())
+	}
+
+	for i, expected := range events {
+		event, err := reader.Next()
+		if err != nil {
+			t.Fatalf("Error reading event %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(event, expected) {
+			t.Errorf("Event %d:\nExpected: %#v\nActual:   %#v", i, expected, event)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stdout")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stale stream file left in the I/O log: %v", err)
+	}
+}
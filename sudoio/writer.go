@@ -0,0 +1,86 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package sudoio
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Writer writes an uncompressed I/O log directory.
+type Writer struct {
+	dir    string
+	timing *os.File
+	files  map[string]*os.File
+}
+
+// Create creates the I/O log directory dir and writes its log file.
+// Existing files in dir are overwritten, and the stream files of an I/O log
+// already in dir are removed, so none of them are left over from it.
+func Create(dir string, log Log) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	for stream := Stdin; stream <= Suspend; stream++ {
+		if !stream.HasData() {
+			continue
+		}
+		err := os.Remove(filepath.Join(dir, stream.File()))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "log"), []byte(log.String()), 0600); err != nil {
+		return nil, err
+	}
+
+	timing, err := os.Create(filepath.Join(dir, "timing"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		dir:    dir,
+		timing: timing,
+		files:  map[string]*os.File{},
+	}, nil
+}
+
+// Write writes an event to the timing file and its data to the stream file.
+func (w *Writer) Write(e Event) error {
+	if e.Stream.HasData() {
+		name := e.Stream.File()
+		file, ok := w.files[name]
+		if !ok {
+			var err error
+			file, err = os.Create(filepath.Join(w.dir, name))
+			if err != nil {
+				return err
+			}
+			w.files[name] = file
+		}
+
+		if _, err := file.WriteString(e.Data); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprint(w.timing, e.timingLine())
+	return err
+}
+
+// Close closes the files of the I/O log.
+func (w *Writer) Close() error {
+	errs := []error{w.timing.Close()}
+	for _, file := range w.files {
+		errs = append(errs, file.Close())
+	}
+	return errors.Join(errs...)
+}
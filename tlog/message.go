@@ -0,0 +1,195 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package tlog reads and writes tlog session recordings.
+//
+// A recording is split into JSON messages. Each message holds the terminal
+// input and output of a stretch of the session in the "in_txt", "in_bin",
+// "out_txt" and "out_bin" fields, and a packed "timing" string made of the records
+//
+//	=WxH	window size
+//	+N	delay of N milliseconds
+//	<N	N characters of input text
+//	[T/B	input which isn't valid UTF-8: T characters of in_txt and B bytes of in_bin
+//	>N	N characters of output text
+//	]T/B	output which isn't valid UTF-8: T characters of out_txt and B bytes of out_bin
+//
+// The messages of a session share a recording ID and are ordered by their position.
+package tlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Version of the message format written by Writer
+const Version = "2.3"
+
+// Metadata identifies the session of a message.
+type Metadata struct {
+	Host    string `json:"host"`
+	Rec     string `json:"rec"` // recording ID
+	User    string `json:"user"`
+	Term    string `json:"term"`
+	Session int    `json:"session"` // audit session ID
+}
+
+// Message is a single JSON message of a recording.
+type Message struct {
+	Ver string `json:"ver"`
+	Metadata
+	ID     int    `json:"id"`  // message number within the recording, from 1
+	Pos    int64  `json:"pos"` // milliseconds since the start of the recording
+	Timing string `json:"timing"`
+	InTxt  string `json:"in_txt"`
+	InBin  []int  `json:"in_bin"`
+	OutTxt string `json:"out_txt"`
+	OutBin []int  `json:"out_bin"`
+}
+
+// EventType is the type of an event, named after its timing record.
+type EventType byte
+
+// Event types
+const (
+	WindowEvent EventType = '='
+	InputEvent  EventType = '<'
+	OutputEvent EventType = '>'
+)
+
+// Event is a window size change, input or output.
+type Event struct {
+	Pos           int64 // milliseconds since the start of the recording
+	Type          EventType
+	Data          string // raw bytes of input and output
+	Width, Height int    // of window events
+}
+
+// One direction of terminal data in a message
+type dataFields struct {
+	txt string
+	bin []int
+}
+
+// Remove n characters from the start of the text
+func (d *dataFields) takeText(n int) (string, error) {
+	i := 0
+	for range n {
+		if i >= len(d.txt) {
+			return "", fmt.Errorf("timing refers past the end of the text")
+		}
+		_, size := utf8.DecodeRuneInString(d.txt[i:])
+		i += size
+	}
+	text := d.txt[:i]
+	d.txt = d.txt[i:]
+	return text, nil
+}
+
+// Remove n bytes from the start of the binary data
+func (d *dataFields) takeBinary(n int) (string, error) {
+	if n > len(d.bin) {
+		return "", fmt.Errorf("timing refers past the end of the binary data")
+	}
+	data := make([]byte, n)
+	for i, b := range d.bin[:n] {
+		if b < 0 || b > 0xff {
+			return "", fmt.Errorf("invalid byte %d in binary data", b)
+		}
+		data[i] = byte(b)
+	}
+	d.bin = d.bin[n:]
+	return string(data), nil
+}
+
+// Events decodes the timing string of m.
+// Input or output split over several consecutive records is returned as one event.
+func (m Message) Events() ([]Event, error) {
+	var events []Event
+	in := dataFields{m.InTxt, m.InBin}
+	out := dataFields{m.OutTxt, m.OutBin}
+	pos := m.Pos
+
+	// Append data, merging it with the previous event if possible
+	add := func(t EventType, data string) {
+		if n := len(events); n > 0 && events[n-1].Type == t && events[n-1].Pos == pos {
+			events[n-1].Data += data
+			return
+		}
+		events = append(events, Event{Pos: pos, Type: t, Data: data})
+	}
+
+	timing := m.Timing
+	for timing != "" {
+		record := timing[0]
+		end := strings.IndexAny(timing[1:], "=+<[>]")
+		if end < 0 {
+			end = len(timing)
+		} else {
+			end++
+		}
+		arg := timing[1:end]
+		timing = timing[end:]
+
+		switch record {
+		case '+':
+			delay, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil || delay < 0 {
+				return nil, fmt.Errorf("invalid delay record %q", "+"+arg)
+			}
+			pos += delay
+		case '=':
+			w, h, ok := strings.Cut(arg, "x")
+			width, err1 := strconv.Atoi(w)
+			height, err2 := strconv.Atoi(h)
+			if !ok || err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("invalid window record %q", "="+arg)
+			}
+			events = append(events, Event{Pos: pos, Type: WindowEvent, Width: width, Height: height})
+		case '<', '>':
+			fields, t := &in, InputEvent
+			if record == '>' {
+				fields, t = &out, OutputEvent
+			}
+
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid text record %q", string(record)+arg)
+			}
+			text, err := fields.takeText(n)
+			if err != nil {
+				return nil, err
+			}
+			add(t, text)
+		case '[', ']':
+			fields, t := &in, InputEvent
+			if record == ']' {
+				fields, t = &out, OutputEvent
+			}
+
+			txt, bin, ok := strings.Cut(arg, "/")
+			nTxt, err1 := strconv.Atoi(txt)
+			nBin, err2 := strconv.Atoi(bin)
+			if !ok || err1 != nil || err2 != nil || nTxt < 0 || nBin < 0 {
+				return nil, fmt.Errorf("invalid binary record %q", string(record)+arg)
+			}
+
+			// The text holds replacement characters for the binary data
+			if _, err := fields.takeText(nTxt); err != nil {
+				return nil, err
+			}
+			data, err := fields.takeBinary(nBin)
+			if err != nil {
+				return nil, err
+			}
+			add(t, data)
+		default:
+			return nil, fmt.Errorf("unknown timing record %q", record)
+		}
+	}
+
+	return events, nil
+}
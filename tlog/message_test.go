@@ -0,0 +1,52 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package tlog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	message := Message{
+		Pos:    1000,
+		Timing: "=80x24+10>5+5<1]1/1>2+20=100x30",
+		InTxt:  "l",
+		OutTxt: "héllo�!\n",
+		OutBin: []int{0xff},
+	}
+
+	expected := []Event{
+		{Pos: 1000, Type: WindowEvent, Width: 80, Height: 24},
+		{Pos: 1010, Type: OutputEvent, Data: "héllo"},
+		{Pos: 1015, Type: InputEvent, Data: "l"},
+		{Pos: 1015, Type: OutputEvent, Data: "\xff!\n"},
+		{Pos: 1035, Type: WindowEvent, Width: 100, Height: 30},
+	}
+
+	events, err := message.Events()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Wrong events:\nExpected: %#v\nActual:   %#v", expected, events)
+	}
+}
+
+func TestEventsInvalid(t *testing.T) {
+	tests := []Message{
+		{Timing: ">5", OutTxt: "abc"},
+		{Timing: "]1/2", OutTxt: "�", OutBin: []int{1}},
+		{Timing: "+x"},
+		{Timing: "=80"},
+		{Timing: "?1"},
+	}
+
+	for _, message := range tests {
+		if _, err := message.Events(); err == nil {
+			t.Errorf("Expected error for timing %q", message.Timing)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package tlog
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Session is a recording reassembled from its messages.
+type Session struct {
+	Metadata
+	Messages []Message // ordered by position
+}
+
+// ReadSessions reads the messages of r, one per line, and groups them into sessions.
+//
+// Messages may come from several interleaved sessions and be out of order,
+// as in a log collected from many hosts. Text before the first "{" of a line,
+// such as a syslog prefix, is ignored, as are lines which aren't messages,
+// including lines which aren't valid JSON.
+// Messages repeated with the same ID are only kept once.
+// Sessions are returned in the order of their first message.
+func ReadSessions(r io.Reader) ([]Session, error) {
+	reader := bufio.NewReader(r)
+	var sessions []*Session
+	index := map[Metadata]int{}
+	seen := map[Metadata]map[int]bool{}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		start := strings.IndexByte(line, '{')
+		if start < 0 {
+			continue
+		}
+
+		var message Message
+		if err := json.Unmarshal([]byte(line[start:]), &message); err != nil {
+			continue // some other text with a brace
+		}
+		if message.Ver == "" || message.ID == 0 {
+			continue // some other JSON
+		}
+
+		key := message.Metadata
+		i, ok := index[key]
+		if !ok {
+			i = len(sessions)
+			index[key] = i
+			seen[key] = map[int]bool{}
+			sessions = append(sessions, &Session{Metadata: key})
+		}
+
+		if seen[key][message.ID] {
+			continue
+		}
+		seen[key][message.ID] = true
+		sessions[i].Messages = append(sessions[i].Messages, message)
+	}
+
+	result := make([]Session, len(sessions))
+	for i, session := range sessions {
+		slices.SortStableFunc(session.Messages, func(a, b Message) int {
+			return cmp.Or(cmp.Compare(a.Pos, b.Pos), cmp.Compare(a.ID, b.ID))
+		})
+		result[i] = *session
+	}
+
+	return result, nil
+}
+
+// Events returns the events of all messages of s.
+func (s Session) Events() ([]Event, error) {
+	var events []Event
+	for _, message := range s.Messages {
+		messageEvents, err := message.Events()
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", message.ID, err)
+		}
+		events = append(events, messageEvents...)
+	}
+	return events, nil
+}
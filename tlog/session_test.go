@@ -0,0 +1,45 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package tlog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadSessions(t *testing.T) {
+	log := `{"ver":"2.3","host":"a","rec":"r1","user":"alice","term":"xterm","session":1,"id":2,"pos":500,"timing":">5","in_txt":"","in_bin":[],"out_txt":"world","out_bin":[]}` + "\n" +
+		`Apr  1 12:34:56 b tlog-rec-session[42]: {"ver":"2.3","host":"b","rec":"r2","user":"bob","term":"xterm","session":2,"id":1,"pos":0,"timing":"=80x24>1","in_txt":"","in_bin":[],"out_txt":"$","out_bin":[]}` + "\n" +
+		"\n" +
+		"Apr  1 12:34:57 a sshd[7]: {not a tlog message\n" +
+		`{"ver":"2.3","host":"a","rec":"r1","user":"alice","term":"xterm","session":1,"id":1,"pos":0,"timing":"=80x24+100>5","in_txt":"","in_bin":[],"out_txt":"hello","out_bin":[]}` + "\n" +
+		`{"ver":"2.3","host":"a","rec":"r1","user":"alice","term":"xterm","session":1,"id":2,"pos":500,"timing":">5","in_txt":"","in_bin":[],"out_txt":"world","out_bin":[]}`
+
+	sessions, err := ReadSessions(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+	if sessions[0].Rec != "r1" || sessions[1].Rec != "r2" {
+		t.Errorf("Wrong session order: %q, %q", sessions[0].Rec, sessions[1].Rec)
+	}
+
+	events, err := sessions[0].Events()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []Event{
+		{Pos: 0, Type: WindowEvent, Width: 80, Height: 24},
+		{Pos: 100, Type: OutputEvent, Data: "hello"},
+		{Pos: 500, Type: OutputEvent, Data: "world"},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Wrong events:\nExpected: %#v\nActual:   %#v", expected, events)
+	}
+}
@@ -0,0 +1,140 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package tlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxSize is the default amount of data in a message written by Writer.
+const DefaultMaxSize = 2048
+
+// Writer splits events into messages of a single session.
+type Writer struct {
+	// A message is written once it holds at least MaxSize bytes of data
+	MaxSize int
+
+	w        io.Writer
+	metadata Metadata
+	id       int
+	message  *Message
+	timing   strings.Builder
+	pos      int64 // of the last record
+	size     int
+}
+
+// NewWriter creates a writer of the session described by metadata.
+func NewWriter(w io.Writer, metadata Metadata) *Writer {
+	return &Writer{
+		MaxSize:  DefaultMaxSize,
+		w:        w,
+		metadata: metadata,
+	}
+}
+
+// Write adds an event to the current message.
+// Events must be written in order of position.
+func (w *Writer) Write(e Event) error {
+	if w.message == nil {
+		w.id++
+		w.message = &Message{
+			Ver:      Version,
+			Metadata: w.metadata,
+			ID:       w.id,
+			Pos:      max(e.Pos, w.pos),
+			InBin:    []int{},
+			OutBin:   []int{},
+		}
+		w.pos = w.message.Pos
+	}
+
+	if delay := e.Pos - w.pos; delay > 0 {
+		fmt.Fprintf(&w.timing, "+%d", delay)
+		w.pos = e.Pos
+	}
+
+	switch e.Type {
+	case WindowEvent:
+		fmt.Fprintf(&w.timing, "=%dx%d", e.Width, e.Height)
+	case InputEvent:
+		w.message.InTxt, w.message.InBin = w.appendData('<', '[', w.message.InTxt, w.message.InBin, e.Data)
+	case OutputEvent:
+		w.message.OutTxt, w.message.OutBin = w.appendData('>', ']', w.message.OutTxt, w.message.OutBin, e.Data)
+	default:
+		return fmt.Errorf("unknown event type %q", e.Type)
+	}
+
+	if w.size >= w.MaxSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Add data to the text and binary fields of a direction, writing text and binary records.
+// Bytes which aren't valid UTF-8 go to the binary field, with a replacement character in the text.
+func (w *Writer) appendData(textRecord, binaryRecord byte, txt string, bin []int, data string) (string, []int) {
+	var text strings.Builder
+	text.WriteString(txt)
+	w.size += len(data)
+
+	for data != "" {
+		// Valid text
+		chars := 0
+		i := 0
+		for i < len(data) {
+			r, size := utf8.DecodeRuneInString(data[i:])
+			if r == utf8.RuneError && size == 1 {
+				break
+			}
+			i += size
+			chars++
+		}
+		if i > 0 {
+			text.WriteString(data[:i])
+			fmt.Fprintf(&w.timing, "%c%d", textRecord, chars)
+			data = data[i:]
+		}
+
+		// Invalid bytes
+		i = 0
+		for i < len(data) {
+			r, size := utf8.DecodeRuneInString(data[i:])
+			if r != utf8.RuneError || size != 1 {
+				break
+			}
+			text.WriteRune(utf8.RuneError)
+			bin = append(bin, int(data[i]))
+			i++
+		}
+		if i > 0 {
+			fmt.Fprintf(&w.timing, "%c%d/%d", binaryRecord, i, i)
+			data = data[i:]
+		}
+	}
+
+	return text.String(), bin
+}
+
+// Flush writes the current message, if any.
+func (w *Writer) Flush() error {
+	if w.message == nil {
+		return nil
+	}
+
+	w.message.Timing = w.timing.String()
+	encoder := json.NewEncoder(w.w)
+	encoder.SetEscapeHTML(false) // keep the timing string readable
+	err := encoder.Encode(w.message)
+
+	w.message = nil
+	w.timing.Reset()
+	w.size = 0
+
+	return err
+}
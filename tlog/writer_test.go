@@ -0,0 +1,53 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package tlog
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestWriterRoundtrip(t *testing.T) {
+	metadata := Metadata{Host: "a", Rec: "r1", User: "alice", Term: "xterm", Session: 1}
+	events := []Event{
+		{Pos: 0, Type: WindowEvent, Width: 80, Height: 24},
+		{Pos: 100, Type: OutputEvent, Data: "héllo \xff\xfeworld"},
+		{Pos: 250, Type: InputEvent, Data: "q"},
+		{Pos: 250, Type: OutputEvent, Data: "bye"},
+		{Pos: 900, Type: OutputEvent, Data: "\x1b[0m"},
+	}
+
+	var buf bytes.Buffer
+	writer := NewWriter(&buf, metadata)
+	writer.MaxSize = 4 // force several messages
+	for i, event := range events {
+		if err := writer.Write(event); err != nil {
+			t.Fatalf("Error writing event %d: %v", i, err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	sessions, err := ReadSessions(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Metadata != metadata {
+		t.Fatalf("Wrong sessions: %#v", sessions)
+	}
+	if len(sessions[0].Messages) < 2 {
+		t.Errorf("Expected several messages, got %d", len(sessions[0].Messages))
+	}
+
+	eventsOut, err := sessions[0].Events()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(events, eventsOut) {
+		t.Errorf("Decoded events not the same as original:\nExpected: %#v\nActual:   %#v", events, eventsOut)
+	}
+}
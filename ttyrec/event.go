@@ -0,0 +1,91 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package ttyrec reads and writes ttyrec recordings.
+//
+// A recording is a sequence of frames, each made of a header
+//
+//	uint32 seconds since the unix epoch
+//	uint32 microseconds
+//	uint32 length of data
+//
+// followed by the data. The integers are little-endian.
+// Only terminal output is recorded.
+package ttyrec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Size of a frame header
+const headerSize = 12
+
+// Longest frame data read. Frames hold what the terminal printed at once,
+// so a longer length means the header is corrupt.
+const maxLength = 1 << 30
+
+type Event struct {
+	Data string
+	Time time.Time
+}
+
+// Take reads the next frame of a recording.
+// It returns io.EOF at the end of the recording, and an error wrapping
+// io.ErrUnexpectedEOF if the last frame is cut off.
+func (e *Event) Take(recording io.Reader) error {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(recording, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("truncated frame header: %w", err)
+		}
+		return err
+	}
+
+	sec := binary.LittleEndian.Uint32(header[0:])
+	usec := binary.LittleEndian.Uint32(header[4:])
+	length := binary.LittleEndian.Uint32(header[8:])
+
+	if length > maxLength {
+		return fmt.Errorf("frame length %d too large", length)
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(recording, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("truncated frame data: %w", io.ErrUnexpectedEOF)
+		}
+		return err
+	}
+
+	e.Data = string(buf)
+	e.Time = time.Unix(int64(sec), int64(usec)*int64(time.Microsecond))
+
+	return nil
+}
+
+// Write writes the event as a frame.
+func (e *Event) Write(recording io.Writer) error {
+	sec := e.Time.Unix()
+	if sec < 0 || sec > 0xffffffff {
+		return fmt.Errorf("time %v out of range for ttyrec", e.Time)
+	}
+
+	var header [headerSize]byte
+	binary.LittleEndian.PutUint32(header[0:], uint32(sec))
+	binary.LittleEndian.PutUint32(header[4:], uint32(e.Time.Nanosecond()/int(time.Microsecond)))
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(e.Data)))
+
+	if _, err := recording.Write(header[:]); err != nil {
+		return err
+	}
+
+	if _, err := recording.Write([]byte(e.Data)); err != nil {
+		return err
+	}
+
+	return nil
+}
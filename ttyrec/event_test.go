@@ -0,0 +1,75 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package ttyrec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRoundtrip(t *testing.T) {
+	start := time.Unix(1743536096, 250000*int64(time.Microsecond))
+	events := []Event{
+		{Data: "hello", Time: start},
+		{Data: "", Time: start.Add(500 * time.Millisecond)},
+		{Data: "world", Time: start.Add(1500 * time.Millisecond)},
+	}
+
+	var recording bytes.Buffer
+	for i, event := range events {
+		if err := event.Write(&recording); err != nil {
+			t.Fatalf("Error writing event %d: %v", i, err)
+		}
+	}
+
+	for i, expected := range events {
+		var event Event
+		if err := event.Take(&recording); err != nil {
+			t.Fatalf("Error reading event %d: %v", i, err)
+		}
+		if event.Data != expected.Data || !event.Time.Equal(expected.Time) {
+			t.Errorf("Event %d:\nExpected: %#v\nActual:   %#v", i, expected, event)
+		}
+	}
+
+	var event Event
+	if err := event.Take(&recording); err != io.EOF {
+		t.Errorf("Expected io.EOF after last frame, got %v", err)
+	}
+}
+
+func TestWriteOutOfRange(t *testing.T) {
+	event := Event{Time: time.Unix(-1, 0)}
+	if err := event.Write(io.Discard); err == nil {
+		t.Errorf("Expected error for time before the epoch")
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	// A frame of "hello"
+	frame := []byte{
+		0xe0, 0x3f, 0xec, 0x67, // seconds
+		0x90, 0xd0, 0x03, 0x00, // microseconds
+		5, 0, 0, 0, // length
+		'h', 'e', 'l', 'l', 'o',
+	}
+
+	// Cut off in the length field, before the data, and in the data
+	for _, length := range []int{10, headerSize, headerSize + 2} {
+		var event Event
+		err := event.Take(bytes.NewReader(frame[:length]))
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%d bytes: Expected io.ErrUnexpectedEOF, got %v", length, err)
+		}
+	}
+
+	var event Event
+	if err := event.Take(bytes.NewReader(frame)); err != nil || event.Data != "hello" {
+		t.Errorf("Whole frame: got %#v, %v", event, err)
+	}
+}
@@ -0,0 +1,112 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package vt
+
+import "strings"
+
+// Color is a cell color: the terminal's default color, an index into the
+// 256 color palette, or a 24-bit RGB color.
+type Color uint32
+
+// Kinds of colors, stored in the top byte
+const (
+	colorIndexed Color = 1 << 24
+	colorRGB     Color = 2 << 24
+	colorKind    Color = 0xff << 24
+)
+
+// DefaultColor is the terminal's default foreground or background color.
+const DefaultColor Color = 0
+
+// IndexedColor returns color i of the 256 color palette.
+// Colors 0 to 15 are the ANSI colors, which themes usually redefine.
+func IndexedColor(i uint8) Color {
+	return colorIndexed | Color(i)
+}
+
+// RGBColor returns a 24-bit color.
+func RGBColor(r, g, b uint8) Color {
+	return colorRGB | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+// IsDefault reports whether c is the default color.
+func (c Color) IsDefault() bool {
+	return c == DefaultColor
+}
+
+// Index returns the palette index of an indexed color.
+func (c Color) Index() (i uint8, ok bool) {
+	return uint8(c), c&colorKind == colorIndexed
+}
+
+// RGB returns the components of a 24-bit color.
+func (c Color) RGB() (r, g, b uint8, ok bool) {
+	return uint8(c >> 16), uint8(c >> 8), uint8(c), c&colorKind == colorRGB
+}
+
+// Flags are the text attributes set by SGR sequences.
+type Flags uint16
+
+// Text attributes
+const (
+	Bold Flags = 1 << iota
+	Faint
+	Italic
+	Underline
+	Blink
+	Inverse
+	Hidden
+	Strikethrough
+)
+
+// Attr is the appearance of a cell.
+type Attr struct {
+	FG, BG Color
+	Flags  Flags
+}
+
+// Cell is a character cell of the screen.
+type Cell struct {
+	// The character of the cell, ' ' for blank cells.
+	// A wide character fills two cells, with the second holding 0.
+	Rune rune
+
+	Combining string // combining characters following Rune
+	Attr      Attr
+}
+
+// Blank cell with the given attributes
+func blank(attr Attr) Cell {
+	return Cell{Rune: ' ', Attr: attr}
+}
+
+// Line is a row of cells.
+type Line struct {
+	Cells []Cell
+
+	// The line continues on the next line, because text wrapped at the right margin
+	Wrapped bool
+}
+
+func newLine(width int, attr Attr) Line {
+	cells := make([]Cell, width)
+	for i := range cells {
+		cells[i] = blank(attr)
+	}
+	return Line{Cells: cells}
+}
+
+// String returns the text of the line without trailing blanks.
+func (l Line) String() string {
+	var b strings.Builder
+	for _, cell := range l.Cells {
+		if cell.Rune == 0 {
+			continue // second half of a wide character
+		}
+		b.WriteRune(cell.Rune)
+		b.WriteString(cell.Combining)
+	}
+	return strings.TrimRight(b.String(), " ")
+}
@@ -0,0 +1,596 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package vt
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Upper bound on the length of sequence parameters and strings,
+// to avoid unbounded memory use for corrupt output
+const maxSequenceLength = 4096
+
+type parserState int
+
+const (
+	stateGround parserState = iota
+	stateEscape             // after ESC
+	stateCSI                // after ESC [
+	stateOSC                // after ESC ]
+	stateString             // in a DCS, SOS, PM or APC string, which are ignored
+)
+
+// parser holds the state of a partly read sequence.
+// It persists across writes, as output is often split in the middle of sequences.
+type parser struct {
+	state        parserState
+	private      byte   // private marker of a CSI sequence, such as '?'
+	intermediate []byte // intermediate bytes of an escape or CSI sequence
+	params       []byte // parameter bytes of a CSI sequence, or the text of an OSC string
+	stringEscape bool   // ESC in a string, which may start ST
+
+	utf8    [utf8.UTFMax]byte // partial UTF-8 character
+	utf8Len int
+}
+
+// Write interprets output written to the terminal. It never fails.
+func (t *Terminal) Write(p []byte) (int, error) {
+	for _, b := range p {
+		t.process(b)
+	}
+	return len(p), nil
+}
+
+// WriteString interprets output written to the terminal. It never fails.
+func (t *Terminal) WriteString(s string) (int, error) {
+	for i := 0; i < len(s); i++ {
+		t.process(s[i])
+	}
+	return len(s), nil
+}
+
+func (t *Terminal) process(b byte) {
+	p := &t.parser
+
+	if p.state == stateGround && (p.utf8Len > 0 || b >= 0x80) {
+		t.processUTF8(b)
+		return
+	}
+
+	// Controls are executed in the middle of sequences
+	switch b {
+	case 0x18, 0x1a: // CAN, SUB
+		p.state = stateGround
+		return
+	case 0x1b: // ESC
+		if p.state == stateOSC || p.state == stateString {
+			p.stringEscape = true
+			return
+		}
+		p.state = stateEscape
+		p.intermediate = p.intermediate[:0]
+		return
+	case 0x07: // BEL
+		if p.state == stateOSC {
+			t.dispatchOSC()
+			p.state = stateGround
+			return
+		}
+	}
+
+	switch p.state {
+	case stateGround:
+		if b < 0x20 || b == 0x7f {
+			t.execute(b)
+		} else {
+			t.print(rune(b))
+		}
+
+	case stateEscape:
+		switch {
+		case b < 0x20:
+			t.execute(b)
+		case b < 0x30:
+			if len(p.intermediate) < maxSequenceLength {
+				p.intermediate = append(p.intermediate, b)
+			}
+		case b == '[' && len(p.intermediate) == 0:
+			p.state = stateCSI
+			p.private = 0
+			p.params = p.params[:0]
+		case b == ']' && len(p.intermediate) == 0:
+			p.state = stateOSC
+			p.params = p.params[:0]
+			p.stringEscape = false
+		case (b == 'P' || b == 'X' || b == '^' || b == '_') && len(p.intermediate) == 0:
+			p.state = stateString
+			p.stringEscape = false
+		case b < 0x7f:
+			p.state = stateGround
+			t.dispatchEscape(b)
+		}
+
+	case stateCSI:
+		switch {
+		case b < 0x20:
+			t.execute(b)
+		case b < 0x30:
+			if len(p.intermediate) < maxSequenceLength {
+				p.intermediate = append(p.intermediate, b)
+			}
+		case b < 0x40:
+			if b >= '<' && len(p.params) == 0 && p.private == 0 {
+				p.private = b
+			} else if len(p.params) < maxSequenceLength {
+				p.params = append(p.params, b)
+			}
+		case b < 0x7f:
+			p.state = stateGround
+			t.dispatchCSI(b)
+		}
+
+	case stateOSC:
+		if p.stringEscape {
+			// ESC \ (ST) ends the string; any other sequence aborts it
+			p.stringEscape = false
+			if b == '\\' {
+				t.dispatchOSC()
+				p.state = stateGround
+			} else {
+				p.state = stateEscape
+				p.intermediate = p.intermediate[:0]
+				t.process(b)
+			}
+			return
+		}
+		if b >= 0x20 && len(p.params) < maxSequenceLength {
+			p.params = append(p.params, b)
+		}
+
+	case stateString:
+		if p.stringEscape {
+			p.stringEscape = false
+			if b == '\\' {
+				p.state = stateGround
+			} else {
+				p.state = stateEscape
+				p.intermediate = p.intermediate[:0]
+				t.process(b)
+			}
+		}
+	}
+}
+
+// Decode UTF-8 output, printing U+FFFD for invalid bytes
+func (t *Terminal) processUTF8(b byte) {
+	p := &t.parser
+
+	if p.utf8Len > 0 {
+		if b < 0x80 || b >= 0xc0 {
+			// Incomplete character
+			p.utf8Len = 0
+			t.print(utf8.RuneError)
+			t.process(b)
+			return
+		}
+		p.utf8[p.utf8Len] = b
+		p.utf8Len++
+		if !utf8.FullRune(p.utf8[:p.utf8Len]) {
+			return
+		}
+
+		r, size := utf8.DecodeRune(p.utf8[:p.utf8Len])
+		rest := append([]byte(nil), p.utf8[size:p.utf8Len]...)
+		p.utf8Len = 0
+		t.print(r)
+		for _, b := range rest {
+			t.process(b)
+		}
+		return
+	}
+
+	if b >= 0xc2 && b <= 0xf4 {
+		p.utf8[0] = b
+		p.utf8Len = 1
+		return
+	}
+	t.print(utf8.RuneError)
+}
+
+// Execute a C0 control character
+func (t *Terminal) execute(b byte) {
+	switch b {
+	case 0x08: // BS
+		if t.wrapNext {
+			t.wrapNext = false
+		} else if t.x > 0 {
+			t.x--
+		}
+	case 0x09: // HT
+		t.tab(1)
+	case 0x0a, 0x0b, 0x0c: // LF, VT, FF
+		t.lineFeed()
+		if t.newlineMode {
+			t.x = 0
+		}
+		t.wrapNext = false
+	case 0x0d: // CR
+		t.x = 0
+		t.wrapNext = false
+	case 0x0e: // SO
+		t.shifted = true
+	case 0x0f: // SI
+		t.shifted = false
+	}
+}
+
+// Dispatch an escape sequence ending in final
+func (t *Terminal) dispatchEscape(final byte) {
+	intermediate := string(t.parser.intermediate)
+
+	switch intermediate {
+	case "":
+		switch final {
+		case '7': // DECSC
+			t.saveCursor()
+		case '8': // DECRC
+			t.restoreCursor()
+		case 'D': // IND
+			t.lineFeed()
+			t.wrapNext = false
+		case 'E': // NEL
+			t.lineFeed()
+			t.x = 0
+			t.wrapNext = false
+		case 'M': // RI
+			t.reverseIndex()
+			t.wrapNext = false
+		case 'H': // HTS
+			t.tabs[t.x] = true
+		case 'c': // RIS
+			t.reset()
+		}
+
+	case "#":
+		if final == '8' { // DECALN
+			for y := range t.lines {
+				for x := range t.lines[y].Cells {
+					t.lines[y].Cells[x] = Cell{Rune: 'E'}
+				}
+				t.lines[y].Wrapped = false
+			}
+			t.top, t.bottom = 0, t.height-1
+			t.moveTo(0, 0)
+		}
+
+	case "(", ")": // SCS for G0 or G1
+		g := 0
+		if intermediate == ")" {
+			g = 1
+		}
+		if final == '0' {
+			t.charsets[g] = '0'
+		} else {
+			t.charsets[g] = 'B'
+		}
+	}
+}
+
+// Parameters of a CSI sequence, each with optional sub-parameters separated by ':'.
+// Missing values are -1.
+type csiParams [][]int
+
+func parseParams(raw []byte) csiParams {
+	if len(raw) == 0 {
+		return nil
+	}
+	var params csiParams
+	for _, param := range strings.Split(string(raw), ";") {
+		var values []int
+		for _, sub := range strings.Split(param, ":") {
+			value, err := strconv.Atoi(sub)
+			if err != nil || value < 0 {
+				value = -1
+			}
+			values = append(values, min(value, 1<<16))
+		}
+		params = append(params, values)
+	}
+	return params
+}
+
+// get returns parameter i, or def if it is missing or 0.
+func (p csiParams) get(i int, def int) int {
+	if i >= len(p) || p[i][0] <= 0 {
+		return def
+	}
+	return p[i][0]
+}
+
+// Dispatch a CSI sequence ending in final
+func (t *Terminal) dispatchCSI(final byte) {
+	p := &t.parser
+	params := parseParams(p.params)
+	intermediate := string(p.intermediate)
+
+	switch {
+	case p.private == '?' && intermediate == "":
+		switch final {
+		case 'h':
+			t.setPrivateModes(params, true)
+		case 'l':
+			t.setPrivateModes(params, false)
+		}
+		return
+	case p.private != 0:
+		return
+	case intermediate == "!":
+		if final == 'p' { // DECSTR
+			t.softReset()
+			t.saved[t.screenIndex()] = savedCursor{}
+		}
+		return
+	case intermediate != "":
+		return
+	}
+
+	n := params.get(0, 1)
+	switch final {
+	case '@': // ICH
+		t.insertChars(n)
+	case 'A': // CUU
+		t.moveLines(-n)
+	case 'B', 'e': // CUD, VPR
+		t.moveLines(n)
+	case 'C', 'a': // CUF, HPR
+		t.moveTo(t.x+n, t.y)
+	case 'D': // CUB
+		t.moveTo(t.x-n, t.y)
+	case 'E': // CNL
+		t.moveLines(n)
+		t.x = 0
+	case 'F': // CPL
+		t.moveLines(-n)
+		t.x = 0
+	case 'G', '`': // CHA, HPA
+		t.moveTo(n-1, t.y)
+	case 'H', 'f': // CUP, HVP
+		t.moveToOrigin(params.get(1, 1)-1, n-1)
+	case 'I': // CHT
+		t.tab(n)
+	case 'J': // ED
+		t.eraseDisplay(params.get(0, 0))
+	case 'K': // EL
+		t.eraseLine(params.get(0, 0))
+	case 'L': // IL
+		t.insertLines(n)
+	case 'M': // DL
+		t.deleteLines(n)
+	case 'P': // DCH
+		t.deleteChars(n)
+	case 'S': // SU
+		t.scrollUp(n)
+	case 'T': // SD
+		if len(params) <= 1 {
+			t.scrollDown(n)
+		}
+	case 'X': // ECH
+		t.erase(t.y, t.x, t.x+n-1)
+		t.wrapNext = false
+	case 'Z': // CBT
+		t.backTab(n)
+	case 'b': // REP
+		if t.lastRune != 0 {
+			for range min(n, t.width*t.height) {
+				t.print(t.lastRune)
+			}
+		}
+	case 'd': // VPA
+		t.moveToOrigin(t.x, n-1)
+	case 'g': // TBC
+		switch params.get(0, 0) {
+		case 0:
+			t.tabs[t.x] = false
+		case 3:
+			clear(t.tabs)
+		}
+	case 'h': // SM
+		t.setModes(params, true)
+	case 'l': // RM
+		t.setModes(params, false)
+	case 'm': // SGR
+		t.setAttributes(params)
+	case 'r': // DECSTBM
+		top, bottom := n-1, params.get(1, t.height)-1
+		if top < bottom && bottom < t.height {
+			t.top, t.bottom = top, bottom
+			t.moveToOrigin(0, 0)
+		}
+	case 's': // SCOSC
+		t.saveCursor()
+	case 'u': // SCORC
+		t.restoreCursor()
+	}
+}
+
+// Set or reset ANSI modes (SM, RM)
+func (t *Terminal) setModes(params csiParams, set bool) {
+	for i := range params {
+		switch params.get(i, 0) {
+		case 4: // IRM
+			t.insertMode = set
+		case 20: // LNM
+			t.newlineMode = set
+		}
+	}
+}
+
+// Set or reset DEC private modes (DECSET, DECRST)
+func (t *Terminal) setPrivateModes(params csiParams, set bool) {
+	for i := range params {
+		switch params.get(i, 0) {
+		case 6: // DECOM
+			t.originMode = set
+			t.moveToOrigin(0, 0)
+		case 7: // DECAWM
+			t.autowrap = set
+			if !set {
+				t.wrapNext = false
+			}
+		case 25: // DECTCEM
+			t.cursorVisible = set
+		case 47, 1047: // alternate screen
+			if !set && t.altScreen && params.get(i, 0) == 1047 {
+				t.clearScreen(t.lines)
+			}
+			t.setAltScreen(set, false)
+		case 1048: // save cursor
+			if set {
+				t.saveCursor()
+			} else {
+				t.restoreCursor()
+			}
+		case 1049: // save cursor and switch to a cleared alternate screen
+			if set {
+				if !t.altScreen {
+					t.saveCursor()
+					t.setAltScreen(true, true)
+					// The alternate screen starts with the main screen's cursor
+					t.saved[1] = t.saved[0]
+				}
+			} else if t.altScreen {
+				t.setAltScreen(false, false)
+				t.restoreCursor()
+			}
+		}
+	}
+}
+
+// Set text attributes (SGR)
+func (t *Terminal) setAttributes(params csiParams) {
+	if len(params) == 0 {
+		t.attr = Attr{}
+		return
+	}
+
+	for i := 0; i < len(params); i++ {
+		param := params[i]
+		switch code := max(param[0], 0); {
+		case code == 0:
+			t.attr = Attr{}
+		case code == 1:
+			t.attr.Flags |= Bold
+		case code == 2:
+			t.attr.Flags |= Faint
+		case code == 3:
+			t.attr.Flags |= Italic
+		case code == 4:
+			if len(param) > 1 && param[1] == 0 {
+				t.attr.Flags &^= Underline // 4:0, no underline
+			} else {
+				t.attr.Flags |= Underline
+			}
+		case code == 5 || code == 6:
+			t.attr.Flags |= Blink
+		case code == 7:
+			t.attr.Flags |= Inverse
+		case code == 8:
+			t.attr.Flags |= Hidden
+		case code == 9:
+			t.attr.Flags |= Strikethrough
+		case code == 21:
+			t.attr.Flags |= Underline // double underline
+		case code == 22:
+			t.attr.Flags &^= Bold | Faint
+		case code == 23:
+			t.attr.Flags &^= Italic
+		case code == 24:
+			t.attr.Flags &^= Underline
+		case code == 25:
+			t.attr.Flags &^= Blink
+		case code == 27:
+			t.attr.Flags &^= Inverse
+		case code == 28:
+			t.attr.Flags &^= Hidden
+		case code == 29:
+			t.attr.Flags &^= Strikethrough
+		case code >= 30 && code <= 37:
+			t.attr.FG = IndexedColor(uint8(code - 30))
+		case code == 38:
+			var color Color
+			color, i = extendedColor(params, i)
+			t.attr.FG = color
+		case code == 39:
+			t.attr.FG = DefaultColor
+		case code >= 40 && code <= 47:
+			t.attr.BG = IndexedColor(uint8(code - 40))
+		case code == 48:
+			var color Color
+			color, i = extendedColor(params, i)
+			t.attr.BG = color
+		case code == 49:
+			t.attr.BG = DefaultColor
+		case code == 58: // underline color, which isn't kept
+			_, i = extendedColor(params, i)
+		case code >= 90 && code <= 97:
+			t.attr.FG = IndexedColor(uint8(code - 90 + 8))
+		case code >= 100 && code <= 107:
+			t.attr.BG = IndexedColor(uint8(code - 100 + 8))
+		}
+	}
+}
+
+// extendedColor parses the color of an SGR 38, 48 or 58 parameter at index i,
+// either as sub-parameters (38:5:n, 38:2::r:g:b) or following parameters
+// (38;5;n, 38;2;r;g;b). It returns the color and the index of the last
+// parameter used.
+func extendedColor(params csiParams, i int) (Color, int) {
+	channel := func(v int) uint8 {
+		return uint8(max(0, min(v, 255)))
+	}
+
+	if param := params[i]; len(param) > 1 {
+		switch param[1] {
+		case 5:
+			if len(param) > 2 {
+				return IndexedColor(channel(param[2])), i
+			}
+		case 2:
+			rgb := param[2:]
+			if len(rgb) > 3 {
+				rgb = rgb[1:] // color space ID
+			}
+			if len(rgb) == 3 {
+				return RGBColor(channel(rgb[0]), channel(rgb[1]), channel(rgb[2])), i
+			}
+		}
+		return DefaultColor, i
+	}
+
+	next := func(j int) int {
+		if i+j < len(params) {
+			return params[i+j][0]
+		}
+		return -1
+	}
+	switch next(1) {
+	case 5:
+		return IndexedColor(channel(next(2))), min(i+2, len(params)-1)
+	case 2:
+		return RGBColor(channel(next(2)), channel(next(3)), channel(next(4))), min(i+4, len(params)-1)
+	}
+	return DefaultColor, min(i+1, len(params)-1)
+}
+
+// Dispatch an OSC string
+func (t *Terminal) dispatchOSC() {
+	command, text, _ := strings.Cut(string(t.parser.params), ";")
+	switch command {
+	case "0", "2":
+		t.title = text
+	}
+}
@@ -0,0 +1,633 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package vt emulates a VT100/xterm terminal, to find out what the output of
+// a recording looks like on screen.
+//
+// A Terminal takes the output of a recording, interprets its control and
+// escape sequences, and keeps the resulting grid of character cells. It
+// handles cursor movement, text attributes and colors (SGR), the alternate
+// screen, scroll regions, line wrapping, insertion and deletion, and resizes.
+// Sequences that only matter to an interactive terminal, such as mouse modes
+// and status reports, are ignored.
+package vt
+
+import (
+	"unicode"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+	"golang.org/x/text/width"
+)
+
+// Terminal is the state of an emulated terminal.
+type Terminal struct {
+	// Lines scrolled off the top of the main screen are kept in the scrollback,
+	// up to MaxScrollback lines. 0 means no limit.
+	MaxScrollback int
+	// KeepScrollback ignores requests to clear the scrollback (ED 3), as sent by clear(1).
+	KeepScrollback bool
+
+	width, height int
+	lines         []Line // the active screen
+	other         []Line // the inactive screen
+	altScreen     bool
+	scrollback    []Line
+
+	x, y     int
+	wrapNext bool // the last column was written, the next character wraps
+	attr     Attr
+	top      int // scroll region, inclusive
+	bottom   int
+	tabs     []bool
+
+	autowrap      bool
+	originMode    bool
+	insertMode    bool
+	newlineMode   bool
+	cursorVisible bool
+	charsets      [2]byte // G0 and G1 character sets, 'B' for ASCII
+	shifted       bool    // G1 is in use
+
+	saved    [2]savedCursor // for the main and alternate screens
+	lastRune rune           // for repeating with REP
+	title    string
+
+	parser parser
+}
+
+// State saved by DECSC
+type savedCursor struct {
+	x, y       int
+	wrapNext   bool
+	attr       Attr
+	originMode bool
+	charsets   [2]byte
+	shifted    bool
+}
+
+// New creates a terminal of the given size.
+func New(width, height int) *Terminal {
+	t := &Terminal{}
+	t.width, t.height = max(width, 1), max(height, 1)
+	t.lines = newScreen(t.width, t.height)
+	t.other = newScreen(t.width, t.height)
+	t.reset()
+	return t
+}
+
+// NewForHeader creates a terminal of the size of an asciicast.
+func NewForHeader(header asciicast.Header) *Terminal {
+	return New(header.Width(), header.Height())
+}
+
+func newScreen(width, height int) []Line {
+	lines := make([]Line, height)
+	for i := range lines {
+		lines[i] = newLine(width, Attr{})
+	}
+	return lines
+}
+
+// Reset the modes and cursor, as for RIS
+func (t *Terminal) reset() {
+	if t.altScreen {
+		t.lines, t.other = t.other, t.lines
+		t.altScreen = false
+	}
+	t.clearScreen(t.lines)
+	t.clearScreen(t.other)
+
+	t.softReset()
+	t.x, t.y = 0, 0
+	t.saved = [2]savedCursor{}
+	t.title = ""
+	t.resetTabs()
+}
+
+// Reset the modes, as for DECSTR
+func (t *Terminal) softReset() {
+	t.wrapNext = false
+	t.attr = Attr{}
+	t.top, t.bottom = 0, t.height-1
+	t.autowrap = true
+	t.originMode = false
+	t.insertMode = false
+	t.newlineMode = false
+	t.cursorVisible = true
+	t.charsets = [2]byte{'B', 'B'}
+	t.shifted = false
+}
+
+func (t *Terminal) resetTabs() {
+	t.tabs = make([]bool, t.width)
+	for i := 8; i < t.width; i += 8 {
+		t.tabs[i] = true
+	}
+}
+
+func (t *Terminal) clearScreen(lines []Line) {
+	for i := range lines {
+		lines[i] = newLine(t.width, Attr{})
+	}
+}
+
+// Size returns the size of the terminal.
+func (t *Terminal) Size() (width, height int) {
+	return t.width, t.height
+}
+
+// Cursor returns the position of the cursor, from 0.
+func (t *Terminal) Cursor() (x, y int) {
+	return t.x, t.y
+}
+
+// CursorVisible reports whether the cursor is shown.
+func (t *Terminal) CursorVisible() bool {
+	return t.cursorVisible
+}
+
+// AltScreen reports whether the alternate screen is active.
+func (t *Terminal) AltScreen() bool {
+	return t.altScreen
+}
+
+// Title returns the window title set by the output.
+func (t *Terminal) Title() string {
+	return t.title
+}
+
+// Cell returns the cell at column x of line y.
+func (t *Terminal) Cell(x, y int) Cell {
+	return t.lines[y].Cells[x]
+}
+
+// Lines returns the lines of the screen.
+// The lines are only valid until the next call to Write or Resize.
+func (t *Terminal) Lines() []Line {
+	return t.lines
+}
+
+// Scrollback returns the lines scrolled off the top of the main screen, oldest first.
+// The lines are only valid until the next call to Write or Resize.
+func (t *Terminal) Scrollback() []Line {
+	return t.scrollback
+}
+
+// Text returns the text of the screen, one line per row, without trailing blanks.
+func (t *Terminal) Text() string {
+	return linesText(t.lines)
+}
+
+func linesText(lines []Line) string {
+	var text []byte
+	for i, line := range lines {
+		if i > 0 {
+			text = append(text, '\n')
+		}
+		text = append(text, line.String()...)
+	}
+	return string(text)
+}
+
+// Apply updates the terminal for an event of an asciicast.
+// Output is written and resize events resize the terminal. Other events are ignored.
+func (t *Terminal) Apply(event asciicast.Event) error {
+	switch event.Code {
+	case asciicast.OutputEvent:
+		t.WriteString(event.Data)
+	case asciicast.ResizeEvent:
+		cols, rows, err := event.Resize()
+		if err != nil {
+			return err
+		}
+		t.Resize(cols, rows)
+	}
+	return nil
+}
+
+// Resize changes the size of the terminal.
+//
+// Lines are cut or extended on the right. When the screen gets shorter,
+// lines above the cursor scroll off the top so it stays on screen.
+func (t *Terminal) Resize(width, height int) {
+	width, height = max(width, 1), max(height, 1)
+	if width == t.width && height == t.height {
+		return
+	}
+
+	resizeLines := func(lines []Line) {
+		for i := range lines {
+			cells := lines[i].Cells
+			if width < len(cells) {
+				cells = cells[:width]
+				if cells[width-1].Rune != 0 && width < len(lines[i].Cells) && lines[i].Cells[width].Rune == 0 {
+					cells[width-1] = blank(cells[width-1].Attr) // cut wide character
+				}
+				lines[i].Wrapped = false
+			}
+			for len(cells) < width {
+				cells = append(cells, blank(Attr{}))
+			}
+			lines[i].Cells = cells
+		}
+	}
+	resizeLines(t.lines)
+	resizeLines(t.other)
+	t.width = width
+
+	// Scroll the lines above the cursor off the top
+	if shift := t.y - height + 1; shift > 0 {
+		if !t.altScreen {
+			t.addScrollback(t.lines[:shift])
+		}
+		t.lines = t.lines[shift:]
+		t.y -= shift
+	}
+	resizeScreen := func(lines []Line) []Line {
+		if len(lines) > height {
+			return lines[:height]
+		}
+		for len(lines) < height {
+			lines = append(lines, newLine(width, Attr{}))
+		}
+		return lines
+	}
+	t.lines = resizeScreen(t.lines)
+	t.other = resizeScreen(t.other)
+	t.height = height
+
+	t.top, t.bottom = 0, height-1
+	t.x = min(t.x, width-1)
+	t.y = min(t.y, height-1)
+	t.wrapNext = false
+	for i := range t.saved {
+		t.saved[i].x = min(t.saved[i].x, width-1)
+		t.saved[i].y = min(t.saved[i].y, height-1)
+	}
+
+	tabs := t.tabs
+	t.resetTabs()
+	copy(t.tabs, tabs)
+}
+
+func (t *Terminal) addScrollback(lines []Line) {
+	for _, line := range lines {
+		t.scrollback = append(t.scrollback, Line{Cells: append([]Cell(nil), line.Cells...), Wrapped: line.Wrapped})
+	}
+	if t.MaxScrollback > 0 && len(t.scrollback) > t.MaxScrollback {
+		t.scrollback = append(t.scrollback[:0:0], t.scrollback[len(t.scrollback)-t.MaxScrollback:]...)
+	}
+}
+
+// Attributes of cells cleared by erasing, which keep the background color
+func (t *Terminal) eraseAttr() Attr {
+	return Attr{BG: t.attr.BG}
+}
+
+// runeWidth returns the number of columns taken by r.
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// DEC special graphics, used for line drawing
+var decGraphics = map[rune]rune{
+	'`': '◆', 'a': '▒', 'b': '␉', 'c': '␌', 'd': '␍', 'e': '␊', 'f': '°', 'g': '±',
+	'h': '␤', 'i': '␋', 'j': '┘', 'k': '┐', 'l': '┌', 'm': '└', 'n': '┼', 'o': '⎺',
+	'p': '⎻', 'q': '─', 'r': '⎼', 's': '⎽', 't': '├', 'u': '┤', 'v': '┴', 'w': '┬',
+	'x': '│', 'y': '≤', 'z': '≥', '{': 'π', '|': '≠', '}': '£', '~': '·',
+}
+
+// Write a printable character at the cursor
+func (t *Terminal) print(r rune) {
+	charset := t.charsets[0]
+	if t.shifted {
+		charset = t.charsets[1]
+	}
+	if charset == '0' {
+		if graphic, ok := decGraphics[r]; ok {
+			r = graphic
+		}
+	}
+
+	w := runeWidth(r)
+	if w == 0 {
+		// Combine with the previous character
+		x := t.x
+		if !t.wrapNext {
+			x--
+		}
+		if x >= 0 && t.lines[t.y].Cells[x].Rune == 0 && x > 0 {
+			x--
+		}
+		if x >= 0 {
+			t.lines[t.y].Cells[x].Combining += string(r)
+		}
+		return
+	}
+	if w > t.width {
+		w = 1
+	}
+
+	if t.wrapNext || (w == 2 && t.x == t.width-1) {
+		if t.autowrap {
+			if !t.wrapNext {
+				t.lines[t.y].Cells[t.x] = blank(t.attr)
+			}
+			t.lines[t.y].Wrapped = true
+			t.x = 0
+			t.lineFeed()
+		} else if w == 2 {
+			t.x = t.width - 2
+		}
+		t.wrapNext = false
+	}
+
+	cells := t.lines[t.y].Cells
+	if t.insertMode {
+		copy(cells[t.x+w:], cells[t.x:])
+		t.fixWideEnd(t.y)
+	}
+
+	t.clearWide(t.y, t.x, t.x+w-1)
+	cells[t.x] = Cell{Rune: r, Attr: t.attr}
+	if w == 2 {
+		cells[t.x+1] = Cell{Rune: 0, Attr: t.attr}
+	}
+	t.lastRune = r
+
+	if t.x+w >= t.width {
+		t.x = t.width - 1
+		t.wrapNext = t.autowrap
+	} else {
+		t.x += w
+	}
+}
+
+// Blank the halves of wide characters that are partly in columns from to to of line y,
+// before they are overwritten
+func (t *Terminal) clearWide(y, from, to int) {
+	cells := t.lines[y].Cells
+	if from > 0 && cells[from].Rune == 0 {
+		cells[from-1] = blank(cells[from-1].Attr)
+	}
+	if to+1 < len(cells) && cells[to+1].Rune == 0 {
+		cells[to+1] = blank(cells[to+1].Attr)
+	}
+}
+
+// Blank a wide character cut in half at the end of line y
+func (t *Terminal) fixWideEnd(y int) {
+	cells := t.lines[y].Cells
+	last := len(cells) - 1
+	if cells[last].Rune != 0 && runeWidth(cells[last].Rune) == 2 {
+		cells[last] = blank(cells[last].Attr)
+	}
+}
+
+// Move the cursor down, scrolling at the bottom of the scroll region
+func (t *Terminal) lineFeed() {
+	if t.y == t.bottom {
+		t.scrollUp(1)
+	} else if t.y < t.height-1 {
+		t.y++
+	}
+}
+
+// Move the cursor up, scrolling at the top of the scroll region
+func (t *Terminal) reverseIndex() {
+	if t.y == t.top {
+		t.scrollDown(1)
+	} else if t.y > 0 {
+		t.y--
+	}
+}
+
+// Scroll the scroll region up by n lines
+func (t *Terminal) scrollUp(n int) {
+	n = min(n, t.bottom-t.top+1)
+	if t.top == 0 && !t.altScreen {
+		t.addScrollback(t.lines[:n])
+	}
+	region := t.lines[t.top : t.bottom+1]
+	copy(region, region[n:])
+	for i := len(region) - n; i < len(region); i++ {
+		region[i] = newLine(t.width, t.eraseAttr())
+	}
+}
+
+// Scroll the scroll region down by n lines
+func (t *Terminal) scrollDown(n int) {
+	n = min(n, t.bottom-t.top+1)
+	region := t.lines[t.top : t.bottom+1]
+	copy(region[n:], region)
+	for i := range n {
+		region[i] = newLine(t.width, t.eraseAttr())
+	}
+}
+
+// Move the cursor, limiting it to the screen
+func (t *Terminal) moveTo(x, y int) {
+	t.x = max(0, min(x, t.width-1))
+	t.y = max(0, min(y, t.height-1))
+	t.wrapNext = false
+}
+
+// Move the cursor to a position relative to the origin, as set by origin mode
+func (t *Terminal) moveToOrigin(x, y int) {
+	if t.originMode {
+		y = max(t.top, min(y+t.top, t.bottom))
+	}
+	t.moveTo(x, y)
+}
+
+// Move the cursor up or down, stopping at the scroll region's margins
+func (t *Terminal) moveLines(n int) {
+	y := t.y + n
+	if t.y >= t.top && t.y <= t.bottom {
+		y = max(t.top, min(y, t.bottom))
+	}
+	t.moveTo(t.x, y)
+}
+
+// Erase columns from to to, inclusive, of line y
+func (t *Terminal) erase(y, from, to int) {
+	from, to = max(from, 0), min(to, t.width-1)
+	if from > to {
+		return
+	}
+	t.clearWide(y, from, to)
+	cells := t.lines[y].Cells
+	for i := from; i <= to; i++ {
+		cells[i] = blank(t.eraseAttr())
+	}
+	if to == t.width-1 {
+		t.lines[y].Wrapped = false
+	}
+}
+
+// Erase in display (ED)
+func (t *Terminal) eraseDisplay(mode int) {
+	switch mode {
+	case 0: // below
+		t.erase(t.y, t.x, t.width-1)
+		for y := t.y + 1; y < t.height; y++ {
+			t.lines[y] = newLine(t.width, t.eraseAttr())
+		}
+	case 1: // above
+		for y := range t.y {
+			t.lines[y] = newLine(t.width, t.eraseAttr())
+		}
+		t.erase(t.y, 0, t.x)
+	case 2: // all
+		for y := range t.lines {
+			t.lines[y] = newLine(t.width, t.eraseAttr())
+		}
+	case 3: // scrollback
+		if !t.KeepScrollback {
+			t.scrollback = nil
+		}
+	}
+}
+
+// Erase in line (EL)
+func (t *Terminal) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		t.erase(t.y, t.x, t.width-1)
+	case 1:
+		t.erase(t.y, 0, t.x)
+	case 2:
+		t.erase(t.y, 0, t.width-1)
+	}
+}
+
+// Insert n blank characters at the cursor (ICH)
+func (t *Terminal) insertChars(n int) {
+	cells := t.lines[t.y].Cells
+	n = min(n, t.width-t.x)
+	t.clearWide(t.y, t.x, t.x)
+	copy(cells[t.x+n:], cells[t.x:])
+	for i := t.x; i < t.x+n; i++ {
+		cells[i] = blank(t.eraseAttr())
+	}
+	t.fixWideEnd(t.y)
+	t.wrapNext = false
+}
+
+// Delete n characters at the cursor (DCH)
+func (t *Terminal) deleteChars(n int) {
+	cells := t.lines[t.y].Cells
+	n = min(n, t.width-t.x)
+	t.clearWide(t.y, t.x, t.x+n-1)
+	copy(cells[t.x:], cells[t.x+n:])
+	for i := t.width - n; i < t.width; i++ {
+		cells[i] = blank(t.eraseAttr())
+	}
+	t.wrapNext = false
+}
+
+// Insert n blank lines at the cursor (IL)
+func (t *Terminal) insertLines(n int) {
+	if t.y < t.top || t.y > t.bottom {
+		return
+	}
+	top := t.top
+	t.top = t.y
+	t.scrollDown(n)
+	t.top = top
+	t.x = 0
+	t.wrapNext = false
+}
+
+// Delete n lines at the cursor (DL)
+func (t *Terminal) deleteLines(n int) {
+	if t.y < t.top || t.y > t.bottom {
+		return
+	}
+	top := t.top
+	t.top = t.y
+	n = min(n, t.bottom-t.top+1)
+	region := t.lines[t.top : t.bottom+1]
+	copy(region, region[n:])
+	for i := len(region) - n; i < len(region); i++ {
+		region[i] = newLine(t.width, t.eraseAttr())
+	}
+	t.top = top
+	t.x = 0
+	t.wrapNext = false
+}
+
+// Move to the next tab stop, n times
+func (t *Terminal) tab(n int) {
+	for ; n > 0 && t.x < t.width-1; n-- {
+		t.x++
+		for t.x < t.width-1 && !t.tabs[t.x] {
+			t.x++
+		}
+	}
+	t.wrapNext = false
+}
+
+// Move to the previous tab stop, n times
+func (t *Terminal) backTab(n int) {
+	for ; n > 0 && t.x > 0; n-- {
+		t.x--
+		for t.x > 0 && !t.tabs[t.x] {
+			t.x--
+		}
+	}
+	t.wrapNext = false
+}
+
+func (t *Terminal) saveCursor() {
+	t.saved[t.screenIndex()] = savedCursor{
+		x:          t.x,
+		y:          t.y,
+		wrapNext:   t.wrapNext,
+		attr:       t.attr,
+		originMode: t.originMode,
+		charsets:   t.charsets,
+		shifted:    t.shifted,
+	}
+}
+
+func (t *Terminal) restoreCursor() {
+	saved := t.saved[t.screenIndex()]
+	if saved.charsets == [2]byte{} {
+		saved.charsets = [2]byte{'B', 'B'} // never saved
+	}
+	t.moveTo(saved.x, saved.y)
+	t.wrapNext = saved.wrapNext
+	t.attr = saved.attr
+	t.originMode = saved.originMode
+	t.charsets = saved.charsets
+	t.shifted = saved.shifted
+}
+
+func (t *Terminal) screenIndex() int {
+	if t.altScreen {
+		return 1
+	}
+	return 0
+}
+
+// Switch between the main and alternate screens
+func (t *Terminal) setAltScreen(alt bool, clear bool) {
+	if alt == t.altScreen {
+		return
+	}
+	t.lines, t.other = t.other, t.lines
+	t.altScreen = alt
+	if clear && alt {
+		t.clearScreen(t.lines)
+	}
+	t.wrapNext = false
+}
@@ -0,0 +1,284 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package vt
+
+import (
+	"testing"
+
+	"github.com/wk-y/asciicast2script/asciicast"
+)
+
+func TestText(t *testing.T) {
+	cases := []struct {
+		name   string
+		output string
+		text   string
+	}{
+		{"plain", "hello\r\nworld", "hello\nworld\n\n"},
+		{"wrap", "abcdefghij", "abcdefgh\nij\n\n"},
+		{"no wrap at margin", "abcdefgh\r\nx", "abcdefgh\nx\n\n"},
+		{"autowrap off", "\x1b[?7labcdefghij", "abcdefgj\n\n\n"},
+		{"cursor position", "\x1b[2;3Hx\x1b[1;1Hy", "y\n  x\n\n"},
+		{"relative moves", "abc\x1b[2Dx\x1b[Bz\x1b[Ay", "axcy\n  z\n\n"},
+		{"erase line", "abcdef\x1b[3G\x1b[K", "ab\n\n\n"},
+		{"erase line start", "abcdef\x1b[3G\x1b[1K", "   def\n\n\n"},
+		{"erase display", "a\r\nb\r\nc\x1b[2;1H\x1b[J", "a\n\n\n"},
+		{"erase chars", "abcdef\x1b[2G\x1b[2X", "a  def\n\n\n"},
+		{"insert chars", "abcdef\x1b[2G\x1b[2@", "a  bcdef\n\n\n"},
+		{"delete chars", "abcdef\x1b[2G\x1b[2P", "adef\n\n\n"},
+		{"insert mode", "abc\x1b[1G\x1b[4hxy\x1b[4lz", "xyzbc\n\n\n"},
+		{"insert lines", "a\r\nb\r\nc\x1b[2H\x1b[L", "a\n\nb\nc"},
+		{"delete lines", "a\r\nb\r\nc\x1b[1H\x1b[M", "b\nc\n\n"},
+		{"tabs", "a\tb\r\x1b[3g\x1b[4G\x1bH\r\tc", "a  c   b\n\n\n"},
+		{"backspace", "abc\b\bx", "axc\n\n\n"},
+		{"carriage return overwrite", "hello\rj", "jello\n\n\n"},
+		{"wide characters", "a日本", "a日本\n\n\n"},
+		{"wide character wraps", "abcdefg日", "abcdefg\n日\n\n"},
+		{"overwrite wide character", "日本\x1b[2Gx", " x本\n\n\n"},
+		{"combining", "éx", "éx\n\n\n"},
+		{"repeat", "a\x1b[3b", "aaaa\n\n\n"},
+		{"line drawing", "\x1b(0lqk\x1b(Bq", "┌─┐q\n\n\n"},
+		{"shift out", "\x1b)0q\x0eq\x0fq", "q─q\n\n\n"},
+		{"invalid utf-8", "a\xffb\xe6\x97c", "a�b�c\n\n\n"},
+		{"ignored sequences", "a\x1b]8;;http://x\x1b\\b\x1bP1$r\x1b\\c\x1b[>4;1m\x1b[?1000hd", "abcd\n\n\n"},
+		{"cancel", "a\x1b[3\x18b", "ab\n\n\n"},
+		{"reset", "abc\x1bcd", "d\n\n\n"},
+		{"alignment", "\x1b#8", "EEEEEEEE\nEEEEEEEE\nEEEEEEEE\nEEEEEEEE"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			term := New(8, 4)
+			term.WriteString(c.output)
+			if text := term.Text(); text != c.text {
+				t.Errorf("Expected %q, got %q", c.text, text)
+			}
+		})
+	}
+}
+
+func TestSplitWrites(t *testing.T) {
+	output := "\x1b[1;31mr\x1b]2;title\x07é日\x1b[0m\x1b[2;2Hx"
+
+	whole := New(10, 3)
+	whole.WriteString(output)
+
+	split := New(10, 3)
+	for i := 0; i < len(output); i++ {
+		split.Write([]byte{output[i]})
+	}
+
+	if whole.Text() != split.Text() {
+		t.Errorf("Expected %q, got %q", whole.Text(), split.Text())
+	}
+	if split.Title() != "title" {
+		t.Errorf("Expected title %q, got %q", "title", split.Title())
+	}
+	if cell := split.Cell(0, 0); cell.Attr != (Attr{FG: IndexedColor(1), Flags: Bold}) {
+		t.Errorf("Wrong attributes %#v", cell.Attr)
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	cases := []struct {
+		sgr  string
+		attr Attr
+	}{
+		{"", Attr{}},
+		{"1;4;7", Attr{Flags: Bold | Underline | Inverse}},
+		{"1;22", Attr{}},
+		{"31;42", Attr{FG: IndexedColor(1), BG: IndexedColor(2)}},
+		{"91;102", Attr{FG: IndexedColor(9), BG: IndexedColor(10)}},
+		{"38;5;200", Attr{FG: IndexedColor(200)}},
+		{"38:5:200", Attr{FG: IndexedColor(200)}},
+		{"48;2;1;2;3;1", Attr{BG: RGBColor(1, 2, 3), Flags: Bold}},
+		{"48:2::1:2:3;1", Attr{BG: RGBColor(1, 2, 3), Flags: Bold}},
+		{"48:2:1:2:3", Attr{BG: RGBColor(1, 2, 3)}},
+		{"31;39", Attr{}},
+		{"3;9;23", Attr{Flags: Strikethrough}},
+		{"4:0", Attr{}},
+		{"38;5", Attr{FG: IndexedColor(0)}},
+	}
+
+	for _, c := range cases {
+		term := New(4, 1)
+		term.WriteString("\x1b[1;41m\x1b[0m\x1b[" + c.sgr + "mx")
+		if attr := term.Cell(0, 0).Attr; attr != c.attr {
+			t.Errorf("SGR %q: expected %#v, got %#v", c.sgr, c.attr, attr)
+		}
+	}
+}
+
+func TestBackgroundErase(t *testing.T) {
+	term := New(4, 2)
+	term.WriteString("ab\x1b[44;1m\x1b[K\r\n\x1b[2K")
+
+	expected := Cell{Rune: ' ', Attr: Attr{BG: IndexedColor(4)}}
+	for _, pos := range [][2]int{{2, 0}, {3, 0}, {0, 1}, {3, 1}} {
+		if cell := term.Cell(pos[0], pos[1]); cell != expected {
+			t.Errorf("Cell %v: expected %#v, got %#v", pos, expected, cell)
+		}
+	}
+	if cell := term.Cell(1, 0); cell.Attr != (Attr{}) {
+		t.Errorf("Cell before cursor erased: %#v", cell)
+	}
+}
+
+func TestScrollback(t *testing.T) {
+	term := New(5, 2)
+	term.WriteString("1\r\n2\r\n3\r\n4")
+
+	if text := linesText(term.Scrollback()); text != "1\n2" {
+		t.Errorf("Wrong scrollback %q", text)
+	}
+	if text := term.Text(); text != "3\n4" {
+		t.Errorf("Wrong screen %q", text)
+	}
+
+	term.MaxScrollback = 1
+	term.WriteString("\r\n5")
+	if text := linesText(term.Scrollback()); text != "3" {
+		t.Errorf("Wrong limited scrollback %q", text)
+	}
+
+	term.KeepScrollback = true
+	term.WriteString("\x1b[3J")
+	if text := linesText(term.Scrollback()); text != "3" {
+		t.Errorf("Kept scrollback cleared: %q", text)
+	}
+
+	term.KeepScrollback = false
+	term.WriteString("\x1b[3J")
+	if len(term.Scrollback()) != 0 {
+		t.Errorf("Scrollback not cleared")
+	}
+}
+
+func TestScrollRegion(t *testing.T) {
+	term := New(5, 4)
+	term.WriteString("a\r\nb\r\nc\r\nd\x1b[2;3r")
+	if x, y := term.Cursor(); x != 0 || y != 0 {
+		t.Errorf("Cursor not homed: %d, %d", x, y)
+	}
+
+	term.WriteString("\x1b[3Hx\r\ny\r\n")
+	if text := term.Text(); text != "a\ny\n\nd" {
+		t.Errorf("Wrong text after scrolling up: %q", text)
+	}
+	if len(term.Scrollback()) != 0 {
+		t.Errorf("Region scrolled into scrollback: %q", linesText(term.Scrollback()))
+	}
+
+	term.WriteString("\x1b[2H\x1bMz")
+	if text := term.Text(); text != "a\nz\ny\nd" {
+		t.Errorf("Wrong text after reverse index: %q", text)
+	}
+
+	term.WriteString("\x1b[?6h\x1b[5;1Hw")
+	if text := term.Text(); text != "a\nz\nw\nd" {
+		t.Errorf("Wrong text with origin mode: %q", text)
+	}
+}
+
+func TestAltScreen(t *testing.T) {
+	term := New(5, 2)
+	term.WriteString("main\x1b[?1049h")
+	if !term.AltScreen() || term.Text() != "\n" {
+		t.Fatalf("Alternate screen not active and clear: %q", term.Text())
+	}
+	if x, y := term.Cursor(); x != 4 || y != 0 {
+		t.Errorf("Cursor moved on switching: %d, %d", x, y)
+	}
+
+	term.WriteString("\x1b[Halt\r\n\n\n")
+	if len(term.Scrollback()) != 0 {
+		t.Errorf("Alternate screen scrolled into scrollback")
+	}
+
+	term.WriteString("\x1b[?1049l")
+	if term.AltScreen() || term.Text() != "main\n" {
+		t.Errorf("Main screen not restored: %q", term.Text())
+	}
+	if x, y := term.Cursor(); x != 4 || y != 0 {
+		t.Errorf("Cursor not restored: %d, %d", x, y)
+	}
+}
+
+func TestSaveCursor(t *testing.T) {
+	term := New(5, 3)
+	term.WriteString("\x1b[2;3H\x1b[1m\x1b7\x1b[0m\x1b[H\x1b8x")
+	if text := term.Text(); text != "\n  x\n" {
+		t.Errorf("Wrong text %q", text)
+	}
+	if attr := term.Cell(2, 1).Attr; attr.Flags != Bold {
+		t.Errorf("Attributes not restored: %#v", attr)
+	}
+}
+
+func TestWrapped(t *testing.T) {
+	term := New(4, 3)
+	term.WriteString("abcdef\r\nxy")
+	lines := term.Lines()
+	if !lines[0].Wrapped || lines[1].Wrapped || lines[2].Wrapped {
+		t.Errorf("Wrong wrapped flags: %v, %v, %v", lines[0].Wrapped, lines[1].Wrapped, lines[2].Wrapped)
+	}
+}
+
+func TestResize(t *testing.T) {
+	term := New(6, 3)
+	term.WriteString("abcdef\r\n12\r\nxyz")
+
+	term.Resize(3, 2)
+	if text := term.Text(); text != "12\nxyz" {
+		t.Errorf("Wrong text after shrinking: %q", text)
+	}
+	if text := linesText(term.Scrollback()); text != "abc" {
+		t.Errorf("Wrong scrollback after shrinking: %q", text)
+	}
+	if x, y := term.Cursor(); x != 2 || y != 1 {
+		t.Errorf("Wrong cursor after shrinking: %d, %d", x, y)
+	}
+
+	term.Resize(4, 3)
+	if width, height := term.Size(); width != 4 || height != 3 {
+		t.Errorf("Wrong size %dx%d", width, height)
+	}
+	term.WriteString("\x1b[3;4Hw")
+	if text := term.Text(); text != "12\nxyz\n   w" {
+		t.Errorf("Wrong text after growing: %q", text)
+	}
+}
+
+func TestApply(t *testing.T) {
+	header := asciicast.HeaderV2Iface{Header: asciicast.HeaderV2{Version: 2, Width: 10, Height: 2}}
+	term := NewForHeader(header)
+
+	events := []asciicast.Event{
+		{Time: 0, Code: asciicast.OutputEvent, Data: "hi"},
+		{Time: 1, Code: asciicast.InputEvent, Data: "ignored"},
+		asciicast.NewResizeEvent(2, 20, 3),
+		asciicast.NewMarkerEvent(3, "m"),
+		{Time: 4, Code: asciicast.OutputEvent, Data: "\x1b[?25l"},
+	}
+	for _, event := range events {
+		if err := term.Apply(event); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if width, height := term.Size(); width != 20 || height != 3 {
+		t.Errorf("Wrong size %dx%d", width, height)
+	}
+	if text := term.Text(); text != "hi\n\n" {
+		t.Errorf("Wrong text %q", text)
+	}
+	if term.CursorVisible() {
+		t.Errorf("Cursor not hidden")
+	}
+
+	if err := term.Apply(asciicast.Event{Code: asciicast.ResizeEvent, Data: "bad"}); err == nil {
+		t.Errorf("Expected error for invalid resize")
+	}
+}